@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/rickykimani/gotex/lexer"
@@ -11,8 +14,76 @@ import (
 	"github.com/rickykimani/gotex/parser"
 	"github.com/rickykimani/gotex/pdf"
 	"github.com/rickykimani/gotex/processor"
+	"github.com/rickykimani/gotex/ttf"
 )
 
+const (
+	// fontDirEnv names the environment variable that overrides the embedded fonts
+	fontDirEnv = "GOTEX_FONT_DIR"
+
+	// sourceDateEpochEnv fixes the build time, see reproducible-builds.org
+	sourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+)
+
+// fontSource returns the font set to compile with: the --font-dir flag wins,
+// then the GOTEX_FONT_DIR environment variable, then the embedded fonts
+func fontSource() (fs.FS, error) {
+	dir := fontDir
+	if dir == "" {
+		dir = os.Getenv(fontDirEnv)
+	}
+	if dir == "" {
+		return ttf.FS, nil
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("font directory: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("font directory: %s is not a directory", dir)
+	}
+	return os.DirFS(dir), nil
+}
+
+// buildTime returns the time recorded in the PDF: SOURCE_DATE_EPOCH when set,
+// so that builds are reproducible, the Unix epoch with --deterministic, and
+// otherwise the current time
+func buildTime() (time.Time, error) {
+	epoch := os.Getenv(sourceDateEpochEnv)
+	if epoch == "" {
+		if deterministic {
+			return time.Unix(0, 0).UTC(), nil
+		}
+		return time.Now(), nil
+	}
+
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %q is not a Unix timestamp", sourceDateEpochEnv, epoch)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// applyMetadataFlags overrides the document information with the values
+// given on the command line
+func applyMetadataFlags(generator *pdf.Generator) {
+	meta := generator.Metadata()
+	if metaTitle != "" {
+		meta.Title = metaTitle
+	}
+	if metaAuthor != "" {
+		meta.Author = metaAuthor
+	}
+	if metaSubject != "" {
+		meta.Subject = metaSubject
+	}
+	if len(metaKeywords) > 0 {
+		meta.Keywords = metaKeywords
+	}
+	generator.SetMetadata(meta)
+}
+
 // compileTeX compiles a .tex file to PDF
 func compileTeX(inputFile, outputFile string) error {
 	// Initialize color functions
@@ -59,19 +130,20 @@ func compileTeX(inputFile, outputFile string) error {
 	// Generate PDF
 	fmt.Println("\nStep 4: Generating PDF...")
 
-	// Get TTF directory path
-	execDir, err := os.Executable()
+	// Fonts are embedded unless an external directory was requested
+	fontFS, err := fontSource()
 	if err != nil {
-		return fmt.Errorf("error getting executable path: %v", err)
+		errorColor.Print("Error: ")
+		return err
 	}
-	ttfDir := filepath.Join(filepath.Dir(execDir), "ttf")
 
-	// Check if ttf directory exists, if not use relative path
-	if _, err := os.Stat(ttfDir); os.IsNotExist(err) {
-		ttfDir = "ttf"
+	built, err := buildTime()
+	if err != nil {
+		errorColor.Print("Error: ")
+		return err
 	}
 
-	generator, err := pdf.NewGenerator(ttfDir)
+	generator, err := pdf.NewGenerator(fontFS)
 	if err != nil {
 		errorColor.Print("Error: ")
 		return fmt.Errorf("creating PDF generator: %v", err)
@@ -79,7 +151,16 @@ func compileTeX(inputFile, outputFile string) error {
 
 	// Process the document and generate PDF
 	docProcessor := processor.NewDocumentProcessor(generator)
+	docProcessor.SetBaseDir(filepath.Dir(inputFile))
+	docProcessor.SetBuildTime(built)
 	docProcessor.ProcessDocument(expandedDoc.Body)
+	applyMetadataFlags(generator)
+
+	warningColor := color.New(color.FgYellow, color.Bold)
+	for _, warning := range docProcessor.Warnings() {
+		warningColor.Print("Warning: ")
+		fmt.Println(warning)
+	}
 
 	err = generator.GeneratePDF(outputFile)
 	if err != nil {
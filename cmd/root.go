@@ -11,8 +11,16 @@ import (
 )
 
 var (
-	outputFile string
-	scanMode   bool
+	outputFile    string
+	scanMode      bool
+	fontDir       string
+	deterministic bool
+
+	// Overrides for the PDF document information
+	metaTitle    string
+	metaAuthor   string
+	metaSubject  string
+	metaKeywords []string
 )
 
 var rootCmd = &cobra.Command{
@@ -25,7 +33,12 @@ Examples:
   gotex document.tex                    # Compile document.tex to document.pdf
   gotex document.tex -o report.pdf     # Compile to custom output name
   gotex assignment                      # Compile assignment.tex (if it exists)
-  gotex --scan                          # Scan current directory for .tex files`,
+  gotex --scan                          # Scan current directory for .tex files
+  gotex document.tex --font-dir ./ttf   # Use fonts from a directory instead of the embedded set
+  gotex document.tex --title "Report"   # Override the PDF title shown by viewers
+
+Set SOURCE_DATE_EPOCH or pass --deterministic to fix the dates in the PDF
+for reproducible, byte-identical builds.`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		// no arguments are needed for scan
 		if scanMode {
@@ -100,6 +113,12 @@ func Execute() {
 func init() {
 	rootCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output PDF file name (default: input basename + .pdf)")
 	rootCmd.Flags().BoolVarP(&scanMode, "scan", "s", false, "Scan current directory for .tex files")
+	rootCmd.Flags().StringVar(&fontDir, "font-dir", "", "Load fonts from this directory instead of the embedded set (env: "+fontDirEnv+")")
+	rootCmd.Flags().BoolVar(&deterministic, "deterministic", false, "Produce byte-identical output for identical input, dated SOURCE_DATE_EPOCH or the Unix epoch")
+	rootCmd.Flags().StringVar(&metaTitle, "title", "", "PDF title (default: \\hypersetup pdftitle or \\title)")
+	rootCmd.Flags().StringVar(&metaAuthor, "author", "", "PDF author (default: \\hypersetup pdfauthor or \\author)")
+	rootCmd.Flags().StringVar(&metaSubject, "subject", "", "PDF subject (default: \\hypersetup pdfsubject)")
+	rootCmd.Flags().StringSliceVar(&metaKeywords, "keywords", nil, "Comma separated PDF keywords (default: \\hypersetup pdfkeywords)")
 }
 
 func scanTexFiles() error {
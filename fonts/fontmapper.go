@@ -1,32 +1,39 @@
 package fonts
 
 import (
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io/fs"
+	"path"
 
 	"github.com/signintech/gopdf"
 )
 
 // FontMapper handles mapping our font styles to TTF files and loading them into gopdf
 type FontMapper struct {
-	pdf      *gopdf.GoPdf
-	fontPath string
-	fonts    map[string]string // Map style ("normal", "bold", etc.) to font key ("dejavu-regular", "cm-bold")
-	loaded   map[string]bool
+	pdf    *gopdf.GoPdf
+	fontFS fs.FS             // Font source, either the embedded set or an external directory
+	fonts  map[string]string // Map style ("normal", "bold", etc.) to font key ("dejavu-regular", "cm-bold")
+	loaded map[string]bool
 }
 
-// NewFontMapper creates a new font mapper for the given PDF instance
-func NewFontMapper(pdf *gopdf.GoPdf, ttfDir string) *FontMapper {
+// NewFontMapper creates a new font mapper for the given PDF instance.
+// Font files are read from fontFS using the layout of the repository's ttf directory.
+func NewFontMapper(pdf *gopdf.GoPdf, fontFS fs.FS) *FontMapper {
 	return &FontMapper{
-		pdf:      pdf,
-		fontPath: ttfDir,
+		pdf:    pdf,
+		fontFS: fontFS,
 		fonts: map[string]string{
-			"normal":      "dejavu-regular",
-			"regular":     "pagella-regular",
-			"bold":        "pagella-bold",
-			"italic":      "pagella-italic",
-			"bold-italic": "pagella-bold-italic",
+			"normal":           "dejavu-regular",
+			"regular":          "pagella-regular",
+			"bold":             "pagella-bold",
+			"italic":           "pagella-italic",
+			"bold-italic":      "pagella-bold-italic",
+			"mono":             "cmu-typewriter",
+			"math-bold":        "dejavu-bold",
+			"math-italic":      "dejavu-oblique",
+			"math-bold-italic": "dejavu-bold-oblique",
+			"math-alphabet":    "dejavu-math",
 		},
 		loaded: make(map[string]bool),
 	}
@@ -36,21 +43,32 @@ func NewFontMapper(pdf *gopdf.GoPdf, ttfDir string) *FontMapper {
 func (fm *FontMapper) LoadFonts() error {
 	//TODO: Find a computer-modern sans with all required symbols
 	//TODO: and replace pagella and dejavu-sans
-	fontFiles := map[string]string{
-		"dejavu-regular":      filepath.Join(fm.fontPath, "dejavu-sans", "DejaVuSans.ttf"),
-		"pagella-regular":     filepath.Join(fm.fontPath, "pagella", "texgyrepagella-regular.ttf"),
-		"pagella-bold":        filepath.Join(fm.fontPath, "pagella", "texgyrepagella-bold.ttf"),
-		"pagella-italic":      filepath.Join(fm.fontPath, "pagella", "texgyrepagella-italic.ttf"),
-		"pagella-bold-italic": filepath.Join(fm.fontPath, "pagella", "texgyrepagella-bolditalic.ttf"),
+	// A slice rather than a map so fonts are always added, and their PDF
+	// objects numbered, in the same order
+	fontFiles := []struct{ key, path string }{
+		{"dejavu-regular", path.Join("dejavu-sans", "DejaVuSans.ttf")},
+		{"dejavu-bold", path.Join("dejavu-sans", "DejaVuSans-Bold.ttf")},
+		{"dejavu-oblique", path.Join("dejavu-sans", "DejaVuSans-Oblique.ttf")},
+		{"dejavu-bold-oblique", path.Join("dejavu-sans", "DejaVuSans-BoldOblique.ttf")},
+		{"dejavu-math", path.Join("dejavu-sans", "DejaVuMathTeXGyre.ttf")},
+		{"pagella-regular", path.Join("pagella", "texgyrepagella-regular.ttf")},
+		{"pagella-bold", path.Join("pagella", "texgyrepagella-bold.ttf")},
+		{"pagella-italic", path.Join("pagella", "texgyrepagella-italic.ttf")},
+		{"pagella-bold-italic", path.Join("pagella", "texgyrepagella-bolditalic.ttf")},
+		{"cmu-typewriter", path.Join("computer-modern", "cmuntt.ttf")},
 	}
 
-	for fontKey, fontPath := range fontFiles {
-		if _, err := os.Stat(fontPath); os.IsNotExist(err) {
+	for _, font := range fontFiles {
+		fontKey, fontPath := font.key, font.path
+		data, err := fs.ReadFile(fm.fontFS, fontPath)
+		if errors.Is(err, fs.ErrNotExist) {
 			return fmt.Errorf("font file not found: %s", fontPath)
+		} else if err != nil {
+			return fmt.Errorf("failed to read font %s: %v", fontPath, err)
 		}
 
-		// Load font into PDF
-		err := fm.pdf.AddTTFFont(fontKey, fontPath)
+		// Load font into PDF from memory
+		err = fm.pdf.AddTTFFontData(fontKey, data)
 		if err != nil {
 			return fmt.Errorf("failed to load font %s: %v", fontKey, err)
 		}
@@ -0,0 +1,82 @@
+package highlight
+
+import "strings"
+
+// Language describes the lexical rules the tokenizer needs for one language
+type Language struct {
+	Name         string
+	Keywords     map[string]bool
+	LineComments []string  // e.g. "//" or "#"
+	BlockComment [2]string // opening and closing delimiters, empty if unsupported
+	Strings      []string  // delimiters of escapable string literals
+	RawStrings   []string  // delimiters of literals without escapes
+	Variables    bool      // highlight $name and ${name} as identifiers (shell)
+}
+
+func keywordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+var golang = &Language{
+	Name: "go",
+	Keywords: keywordSet(`break case chan const continue default defer else fallthrough
+		for func go goto if import interface map package range return select struct
+		switch type var true false nil iota any bool byte complex64 complex128 error
+		float32 float64 int int8 int16 int32 int64 rune string uint uint8 uint16
+		uint32 uint64 uintptr append cap close copy delete len make new panic print
+		println recover min max clear`),
+	LineComments: []string{"//"},
+	BlockComment: [2]string{"/*", "*/"},
+	Strings:      []string{`"`, `'`},
+	RawStrings:   []string{"`"},
+}
+
+var python = &Language{
+	Name: "python",
+	Keywords: keywordSet(`False None True and as assert async await break class continue
+		def del elif else except finally for from global if import in is lambda
+		nonlocal not or pass raise return try while with yield match case self
+		print len range int str float list dict set tuple bool`),
+	LineComments: []string{"#"},
+	Strings:      []string{`"""`, `'''`, `"`, `'`},
+}
+
+var shell = &Language{
+	Name: "bash",
+	Keywords: keywordSet(`if then else elif fi case esac for while until do done in
+		function select time return exit break continue local export readonly
+		declare unset shift source alias echo cd set eval exec test`),
+	LineComments: []string{"#"},
+	Strings:      []string{`"`},
+	RawStrings:   []string{`'`},
+	Variables:    true,
+}
+
+// languages maps the names accepted by language= and minted to their rules
+var languages = map[string]*Language{
+	"go":      golang,
+	"golang":  golang,
+	"python":  python,
+	"py":      python,
+	"python3": python,
+	"bash":    shell,
+	"sh":      shell,
+	"shell":   shell,
+	"zsh":     shell,
+	"console": shell,
+}
+
+// LookupLanguage returns the rules for a language name, ignoring case and
+// listings dialects such as "[Sharp]C"
+func LookupLanguage(name string) (*Language, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if i := strings.Index(name, "]"); i >= 0 {
+		name = strings.TrimSpace(name[i+1:])
+	}
+	lang, ok := languages[name]
+	return lang, ok
+}
@@ -0,0 +1,208 @@
+// Package highlight splits source code into coloured token classes for
+// code listings. It understands just enough of each language to find
+// keywords, string and number literals and comments.
+package highlight
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenKind classifies a piece of source code
+type TokenKind int
+
+const (
+	Plain TokenKind = iota
+	Keyword
+	String
+	Comment
+	Number
+	Variable
+)
+
+// Token is a run of source text with a single kind. Tokens never span
+// lines, so a listing can be rendered line by line.
+type Token struct {
+	Kind TokenKind
+	Text string
+}
+
+// Tokenize splits source into lines of tokens. A nil language yields
+// every line as a single plain token.
+func Tokenize(source string, lang *Language) [][]Token {
+	t := &tokenizer{src: source, lang: lang}
+	t.lines = [][]Token{{}}
+
+	if lang == nil {
+		t.emit(Plain, source)
+		return t.lines
+	}
+
+	for t.pos < len(t.src) {
+		t.next()
+	}
+	return t.lines
+}
+
+type tokenizer struct {
+	src   string
+	pos   int
+	lang  *Language
+	lines [][]Token
+}
+
+// emit appends text of the given kind, starting a new line at each newline
+func (t *tokenizer) emit(kind TokenKind, text string) {
+	for i, part := range strings.Split(text, "\n") {
+		if i > 0 {
+			t.lines = append(t.lines, []Token{})
+		}
+		if part == "" {
+			continue
+		}
+
+		line := &t.lines[len(t.lines)-1]
+		if n := len(*line); n > 0 && (*line)[n-1].Kind == kind {
+			(*line)[n-1].Text += part
+		} else {
+			*line = append(*line, Token{Kind: kind, Text: part})
+		}
+	}
+}
+
+func (t *tokenizer) rest() string {
+	return t.src[t.pos:]
+}
+
+// take emits the next n bytes as kind
+func (t *tokenizer) take(kind TokenKind, n int) {
+	n = min(n, len(t.src)-t.pos)
+	t.emit(kind, t.src[t.pos:t.pos+n])
+	t.pos += n
+}
+
+func (t *tokenizer) next() {
+	rest := t.rest()
+
+	for _, marker := range t.lang.LineComments {
+		if strings.HasPrefix(rest, marker) {
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			t.take(Comment, end)
+			return
+		}
+	}
+
+	if open, closing := t.lang.BlockComment[0], t.lang.BlockComment[1]; open != "" && strings.HasPrefix(rest, open) {
+		end := strings.Index(rest[len(open):], closing)
+		if end < 0 {
+			t.take(Comment, len(rest))
+		} else {
+			t.take(Comment, len(open)+end+len(closing))
+		}
+		return
+	}
+
+	for _, delim := range t.lang.RawStrings {
+		if strings.HasPrefix(rest, delim) {
+			t.take(String, literalLength(rest, delim, false))
+			return
+		}
+	}
+	for _, delim := range t.lang.Strings {
+		if strings.HasPrefix(rest, delim) {
+			t.take(String, literalLength(rest, delim, true))
+			return
+		}
+	}
+
+	ch, size := utf8.DecodeRuneInString(rest)
+	switch {
+	case t.lang.Variables && ch == '$' && len(rest) > 1:
+		t.take(Variable, variableLength(rest))
+	case isDigit(ch) || (ch == '.' && len(rest) > 1 && isDigit(rune(rest[1]))):
+		t.take(Number, numberLength(rest))
+	case isIdentStart(ch):
+		n := identLength(rest)
+		if t.lang.Keywords[rest[:n]] {
+			t.take(Keyword, n)
+		} else {
+			t.take(Plain, n)
+		}
+	default:
+		t.take(Plain, size)
+	}
+}
+
+// literalLength returns the length of the literal at the start of s,
+// including both delimiters. Single-character delimiters other than
+// backquotes stop at the end of the line.
+func literalLength(s, delim string, escapes bool) int {
+	singleLine := len(delim) == 1 && delim != "`"
+	i := len(delim)
+	for i < len(s) {
+		switch {
+		case escapes && s[i] == '\\':
+			i += 2
+			continue
+		case strings.HasPrefix(s[i:], delim):
+			return i + len(delim)
+		case singleLine && s[i] == '\n':
+			return i
+		}
+		i++
+	}
+	return len(s)
+}
+
+func numberLength(s string) int {
+	i := 0
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		i = 2
+	}
+	for i < len(s) && (isIdentChar(rune(s[i])) || s[i] == '.') {
+		i++
+	}
+	return i
+}
+
+func variableLength(s string) int {
+	if s[1] == '{' {
+		if end := strings.IndexByte(s, '}'); end >= 0 {
+			return end + 1
+		}
+		return len(s)
+	}
+	if !isIdentChar(rune(s[1])) {
+		// Special parameters such as $? and $@
+		return 2
+	}
+	return 1 + identLength(s[1:])
+}
+
+func identLength(s string) int {
+	i := 0
+	for i < len(s) {
+		ch, size := utf8.DecodeRuneInString(s[i:])
+		if !isIdentChar(ch) {
+			break
+		}
+		i += size
+	}
+	return max(i, 1)
+}
+
+func isDigit(ch rune) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isIdentStart(ch rune) bool {
+	return ch == '_' || unicode.IsLetter(ch)
+}
+
+func isIdentChar(ch rune) bool {
+	return ch == '_' || unicode.IsLetter(ch) || unicode.IsDigit(ch)
+}
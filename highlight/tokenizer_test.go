@@ -0,0 +1,56 @@
+package highlight
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTokenizeGo(t *testing.T) {
+	source := "func main() {\n\ts := `a` + \"b\\\"c\" // done\n\t/* multi\n\tline */ x := 0x1F\n}"
+
+	lang, ok := LookupLanguage("Go")
+	if !ok {
+		t.Fatal("Go should be a known language")
+	}
+
+	lines := Tokenize(source, lang)
+	for i, line := range lines {
+		fmt.Printf("%d: %q\n", i+1, line)
+	}
+
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 lines, got %d", len(lines))
+	}
+
+	expected := map[TokenKind][]string{
+		Keyword: {"func"},
+		String:  {"`a`", `"b\"c"`},
+		Comment: {"// done", "/* multi", "\tline */"},
+		Number:  {"0x1F"},
+	}
+	found := make(map[TokenKind][]string)
+	for _, line := range lines {
+		for _, tok := range line {
+			if tok.Kind != Plain {
+				found[tok.Kind] = append(found[tok.Kind], tok.Text)
+			}
+		}
+	}
+
+	for kind, texts := range expected {
+		if fmt.Sprint(found[kind]) != fmt.Sprint(texts) {
+			t.Errorf("Kind %d: expected %q, got %q", kind, texts, found[kind])
+		}
+	}
+}
+
+func TestTokenizeUnknownLanguage(t *testing.T) {
+	if _, ok := LookupLanguage("cobol"); ok {
+		t.Error("cobol should not be a known language")
+	}
+
+	lines := Tokenize("if x\n# not a comment", nil)
+	if len(lines) != 2 || len(lines[1]) != 1 || lines[1][0].Kind != Plain {
+		t.Errorf("Expected plain lines, got %q", lines)
+	}
+}
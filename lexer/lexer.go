@@ -1,5 +1,7 @@
 package lexer
 
+import "strings"
+
 // LexerInterface defines the interface for tokenizers
 type LexerInterface interface {
 	NextToken() Token
@@ -21,6 +23,38 @@ type Lexer struct {
 	readPos int  // next position
 	ch      rune // current char
 	posInfo Position
+	pending []Token // tokens already lexed, returned before reading further input
+
+	// Spaces after a control symbol are kept, unlike those after a command
+	keepSpace bool
+}
+
+// verbatimEnvironments lists environments whose body is captured raw,
+// without interpreting \, $, {, } or %, and how many required {...}
+// arguments follow \begin{name} (an optional [...] is always allowed)
+var verbatimEnvironments = map[string]int{
+	"verbatim":   0,
+	"verbatim*":  0,
+	"lstlisting": 0,
+	"minted":     1,
+}
+
+// mathEnvironments lists environments whose body is math, read raw like
+// the text between \[ and \], and whether the math is inline
+var mathEnvironments = map[string]bool{
+	"math":        true,
+	"displaymath": false,
+	"equation*":   false,
+}
+
+// starredCommands lists commands and control symbols whose name may end in *
+var starredCommands = map[string]bool{
+	"hspace":              true,
+	"vspace":              true,
+	"operatorname":        true,
+	"DeclareMathOperator": true,
+	"tag":                 true,
+	"\\":                  true,
 }
 
 func (l *Lexer) readChar() {
@@ -44,7 +78,16 @@ func (l *Lexer) readChar() {
 func (l *Lexer) NextToken() Token {
 	var tok Token
 
-	l.skipWhitespace()
+	if len(l.pending) > 0 {
+		tok = l.pending[0]
+		l.pending = l.pending[1:]
+		return tok
+	}
+
+	if !l.keepSpace {
+		l.skipWhitespace()
+	}
+	l.keepSpace = false
 
 	switch l.ch {
 	case '\\':
@@ -57,6 +100,11 @@ func (l *Lexer) NextToken() Token {
 	case '}':
 		tok = Token{Type: TokenRBrace, Value: "}", Pos: l.posInfo}
 		l.readChar()
+
+		// Spaces after a group are kept, as in `\ldots{} and` or
+		// `\ref{a} and`, since the group ends the command and its
+		// arguments; spaces at the end of a line are dropped as TeX does
+		l.keepSpace = !l.atLineEnd()
 	case '[':
 		tok = l.lexOptionalArg()
 	case '%':
@@ -88,6 +136,27 @@ func (l *Lexer) lexCommand() Token {
 		l.readChar()
 	}
 
+	// A backslash and one other character form a control symbol
+	if value == "" && l.ch != 0 {
+		return l.lexControlSymbol(pos)
+	}
+
+	if l.ch == '*' && starredCommands[value] {
+		value += "*"
+		l.readChar()
+	}
+
+	// \verb takes its argument between two copies of any delimiter character
+	if value == "verb" {
+		return l.lexVerb(pos)
+	}
+
+	// URLs may contain %, #, _ and ~, so their argument is read raw
+	if value == "url" || value == "href" {
+		l.lexURL()
+		return Token{Type: TokenCommand, Value: value, Pos: pos}
+	}
+
 	// Handle special cases like \begin and \end
 	if value == "begin" || value == "end" {
 		// Skip whitespace before {
@@ -126,6 +195,12 @@ func (l *Lexer) lexCommand() Token {
 					l.readChar() // skip }
 
 					if value == "begin" {
+						if inline, ok := mathEnvironments[envName]; ok {
+							return l.lexMathUntil("\\end{"+envName+"}", inline, pos)
+						}
+						if args, ok := verbatimEnvironments[envName]; ok {
+							l.lexVerbatimEnv(envName, args)
+						}
 						return Token{Type: TokenBeginEnv, Value: envName, Pos: pos}
 					} else {
 						return Token{Type: TokenEndEnv, Value: envName, Pos: pos}
@@ -139,6 +214,164 @@ func (l *Lexer) lexCommand() Token {
 
 	return Token{Type: TokenCommand, Value: value, Pos: pos}
 }
+
+// lexControlSymbol reads the character after a backslash that is not a
+// letter. A control space, written as a backslash before a space or line
+// break, has the value " ". Spaces after control symbols are kept, except
+// after a control space and after \\, which looks past them for its
+// optional argument.
+func (l *Lexer) lexControlSymbol(pos Position) Token {
+	value := string(l.ch)
+	l.readChar()
+
+	// \( and \[ open inline and display math
+	switch value {
+	case "(":
+		return l.lexMathUntil("\\)", true, pos)
+	case "[":
+		return l.lexMathUntil("\\]", false, pos)
+	}
+
+	if value == "\n" || value == "\t" || value == "\r" {
+		value = " "
+	}
+	if l.ch == '*' && starredCommands[value] {
+		value += "*"
+		l.readChar()
+	}
+
+	l.keepSpace = value != " " && value != "\\" && value != "\\*"
+	return Token{Type: TokenControlSymbol, Value: value, Pos: pos}
+}
+
+// lexVerb reads \verb|...| or \verb*|...| after the command name. The command
+// token is returned and the raw argument is queued as a TokenVerbatim.
+func (l *Lexer) lexVerb(pos Position) Token {
+	name := "verb"
+	if l.ch == '*' {
+		name = "verb*"
+		l.readChar()
+	}
+
+	delim := l.ch
+	if delim == 0 || delim == '\n' || delim == ' ' || isLetter(delim) {
+		// No usable delimiter, leave the rest of the input alone
+		return Token{Type: TokenCommand, Value: name, Pos: pos}
+	}
+	l.readChar() // skip opening delimiter
+
+	start := l.pos
+	textPos := l.posInfo
+	for l.ch != delim && l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+	value := l.input[start:l.pos]
+	if l.ch == delim {
+		l.readChar() // skip closing delimiter
+	}
+
+	l.pending = append(l.pending, Token{Type: TokenVerbatim, Value: value, Pos: textPos})
+	return Token{Type: TokenCommand, Value: name, Pos: pos}
+}
+
+// lexURL reads the braced URL argument of \url or \href without
+// interpreting special characters and queues it as a TokenVerbatim.
+// Nested braces are kept as long as they balance.
+func (l *Lexer) lexURL() {
+	l.skipWhitespace()
+	if l.ch != '{' {
+		return
+	}
+	l.readChar() // skip {
+
+	start := l.pos
+	textPos := l.posInfo
+	depth := 1
+	for l.ch != 0 {
+		if l.ch == '{' {
+			depth++
+		} else if l.ch == '}' {
+			depth--
+			if depth == 0 {
+				break
+			}
+		}
+		l.readChar()
+	}
+	value := l.input[start:l.pos]
+	if l.ch == '}' {
+		l.readChar() // skip }
+	}
+
+	l.pending = append(l.pending, Token{Type: TokenVerbatim, Value: value, Pos: textPos})
+}
+
+// lexVerbatimEnv captures the body of a verbatim environment up to its
+// \end{name} and queues it as a TokenVerbatim followed by the TokenEndEnv.
+// Arguments given on the \begin line are queued first as ordinary tokens.
+// The line break right after \begin{name} and the one before \end{name}
+// are not part of the content.
+func (l *Lexer) lexVerbatimEnv(name string, args int) {
+	end := "\\end{" + name + "}"
+
+	l.skipWhitespace()
+	if l.ch == '[' {
+		l.pending = append(l.pending, l.lexOptionalArg())
+	}
+	for range args {
+		l.skipWhitespace()
+		if l.ch != '{' {
+			break
+		}
+		l.pending = append(l.pending, Token{Type: TokenLBrace, Value: "{", Pos: l.posInfo})
+		l.readChar()
+
+		argPos := l.posInfo
+		start := l.pos
+		for l.ch != '}' && l.ch != '\n' && l.ch != 0 {
+			l.readChar()
+		}
+		if l.pos > start {
+			l.pending = append(l.pending, Token{Type: TokenText, Value: l.input[start:l.pos], Pos: argPos})
+		}
+		if l.ch == '}' {
+			l.pending = append(l.pending, Token{Type: TokenRBrace, Value: "}", Pos: l.posInfo})
+			l.readChar()
+		}
+	}
+
+	// Skip trailing blanks and the newline that ends the \begin line
+	for l.ch == ' ' || l.ch == '\t' || l.ch == '\r' {
+		l.readChar()
+	}
+	if l.ch == '\n' {
+		l.readChar()
+	}
+
+	start := l.pos
+	textPos := l.posInfo
+	for l.ch != 0 && !strings.HasPrefix(l.input[l.pos:], end) {
+		l.readChar()
+	}
+
+	value := l.input[start:min(l.pos, len(l.input))]
+	if i := strings.LastIndex(value, "\n"); i >= 0 && strings.TrimSpace(value[i:]) == "" {
+		value = value[:i]
+	}
+	l.pending = append(l.pending, Token{Type: TokenVerbatim, Value: value, Pos: textPos})
+
+	if l.ch == 0 {
+		// Missing \end{name}; the parser reports the unclosed environment
+		return
+	}
+
+	endPos := l.posInfo
+	for range end {
+		l.readChar()
+	}
+	l.pending = append(l.pending, Token{Type: TokenEndEnv, Value: name, Pos: endPos})
+}
+
 func (l *Lexer) lexMath() Token {
 	pos := l.posInfo
 
@@ -151,7 +384,8 @@ func (l *Lexer) lexMath() Token {
 		value := ""
 		for {
 			if l.ch == 0 {
-				break // EOF
+				// EOF reached without closing $$
+				return Token{Type: TokenMathDisplay, Value: value, Pos: pos, Unterminated: true}
 			}
 			if l.ch == '$' && l.readPos < len(l.input) && rune(l.input[l.readPos]) == '$' {
 				l.readChar() // skip first $
@@ -167,21 +401,76 @@ func (l *Lexer) lexMath() Token {
 		// Inline math $...$
 		l.readChar() // skip $
 
-		value := ""
-		for l.ch != '$' && l.ch != 0 {
-			value += string(l.ch)
-			l.readChar()
+		// A $ in braces, as in \text{if $y$}, starts math nested in text
+		// rather than ending this math, and one escaped as \$ is a dollar
+		start := l.pos
+		depth := 0
+		for l.ch != 0 && (l.ch != '$' || depth > 0) && !l.atBlankLine() {
+			switch l.ch {
+			case '\\':
+				l.readChar()
+			case '{':
+				depth++
+			case '}':
+				depth = max(depth-1, 0)
+			}
+			if l.ch != 0 {
+				l.readChar()
+			}
 		}
-		if l.ch == '$' {
-			l.readChar() // skip closing $
-		} else {
-			// EOF reached without closing $, but we'll still return the token
-			// The parser can detect this if needed by checking for incomplete math
+		value := l.input[start:min(l.pos, len(l.input))]
+		if l.ch != '$' {
+			// The paragraph or the input ended without a closing $
+			return Token{Type: TokenMathInline, Value: value, Pos: pos, Unterminated: true}
 		}
+		l.readChar() // skip closing $
 
 		return Token{Type: TokenMathInline, Value: value, Pos: pos}
 	}
 }
+
+// lexMathUntil reads math after an opening delimiter up to the closing
+// delimiter end, which is skipped. Inline math also stops at a blank line,
+// since it cannot span paragraphs. The token is marked unterminated when
+// end is missing.
+func (l *Lexer) lexMathUntil(end string, inline bool, pos Position) Token {
+	tok := Token{Type: TokenMathDisplay, Pos: pos}
+	if inline {
+		tok.Type = TokenMathInline
+	}
+
+	start := l.pos
+	for l.ch != 0 && !strings.HasPrefix(l.input[l.pos:], end) && !(inline && l.atBlankLine()) {
+		l.readChar()
+	}
+	tok.Value = l.input[start:min(l.pos, len(l.input))]
+
+	if l.ch == 0 || !strings.HasPrefix(l.input[l.pos:], end) {
+		tok.Unterminated = true
+		return tok
+	}
+	for range end {
+		l.readChar()
+	}
+	return tok
+}
+
+// atLineEnd reports whether only spaces are left on the current line
+func (l *Lexer) atLineEnd() bool {
+	rest := strings.TrimLeft(l.input[min(l.pos, len(l.input)):], " \t\r")
+	return rest == "" || rest[0] == '\n'
+}
+
+// atBlankLine reports whether the current character ends a line that is
+// followed by a blank one
+func (l *Lexer) atBlankLine() bool {
+	if l.ch != '\n' {
+		return false
+	}
+	rest := strings.TrimLeft(l.input[min(l.readPos, len(l.input)):], " \t\r")
+	return strings.HasPrefix(rest, "\n")
+}
+
 func (l *Lexer) lexText() Token {
 	pos := l.posInfo
 	value := ""
@@ -34,12 +34,305 @@ $$\sum_{i=1}^{n} i = \frac{n(n+1)}{2}$$
 	}
 }
 
+func TestVerbatim(t *testing.T) {
+	input := "\\verb|$x% {y}| then\n\\begin{verbatim}\n  if (a) { b; } % kept\n\\end{verbatim}\n"
+
+	tokens := NewLexer(input).Tokenize()
+	for _, token := range tokens {
+		fmt.Printf("Type: %-15s Value: %q\n", tokenTypeToString(token.Type), token.Value)
+	}
+
+	expected := []struct {
+		Type  TokenType
+		Value string
+	}{
+		{TokenCommand, "verb"},
+		{TokenVerbatim, "$x% {y}"},
+		{TokenText, "then"},
+		{TokenText, "\n"},
+		{TokenBeginEnv, "verbatim"},
+		{TokenVerbatim, "  if (a) { b; } % kept"},
+		{TokenEndEnv, "verbatim"},
+		{TokenText, "\n"},
+		{TokenEOF, ""},
+	}
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %d tokens, got %d", len(expected), len(tokens))
+	}
+	for i, exp := range expected {
+		if tokens[i].Type != exp.Type || tokens[i].Value != exp.Value {
+			t.Errorf("Token %d: expected %s %q, got %s %q", i,
+				tokenTypeToString(exp.Type), exp.Value,
+				tokenTypeToString(tokens[i].Type), tokens[i].Value)
+		}
+	}
+}
+
+func TestURL(t *testing.T) {
+	input := "\\url{https://example.com/a_b%20c#top} \\href{http://x.org/~me}{home}"
+
+	tokens := NewLexer(input).Tokenize()
+	for _, token := range tokens {
+		fmt.Printf("Type: %-15s Value: %q\n", tokenTypeToString(token.Type), token.Value)
+	}
+
+	expected := []struct {
+		Type  TokenType
+		Value string
+	}{
+		{TokenCommand, "url"},
+		{TokenVerbatim, "https://example.com/a_b%20c#top"},
+		{TokenCommand, "href"},
+		{TokenVerbatim, "http://x.org/~me"},
+		{TokenLBrace, "{"},
+		{TokenText, "home"},
+		{TokenRBrace, "}"},
+		{TokenEOF, ""},
+	}
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %d tokens, got %d", len(expected), len(tokens))
+	}
+	for i, exp := range expected {
+		if tokens[i].Type != exp.Type || tokens[i].Value != exp.Value {
+			t.Errorf("Token %d: expected %s %q, got %s %q", i,
+				tokenTypeToString(exp.Type), exp.Value,
+				tokenTypeToString(tokens[i].Type), tokens[i].Value)
+		}
+	}
+}
+
+func TestSpacingCommands(t *testing.T) {
+	input := "a\\,b \\hspace*{1cm plus 1fill}\\quad Dr.~Who"
+
+	tokens := NewLexer(input).Tokenize()
+	for _, token := range tokens {
+		fmt.Printf("Type: %-15s Value: %q\n", tokenTypeToString(token.Type), token.Value)
+	}
+
+	expected := []struct {
+		Type  TokenType
+		Value string
+	}{
+		{TokenText, "a"},
+		{TokenControlSymbol, ","},
+		{TokenText, "b "},
+		{TokenCommand, "hspace*"},
+		{TokenLBrace, "{"},
+		{TokenText, "1cm plus 1fill"},
+		{TokenRBrace, "}"},
+		{TokenCommand, "quad"},
+		{TokenText, "Dr.~Who"},
+		{TokenEOF, ""},
+	}
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %d tokens, got %d", len(expected), len(tokens))
+	}
+	for i, exp := range expected {
+		if tokens[i].Type != exp.Type || tokens[i].Value != exp.Value {
+			t.Errorf("Token %d: expected %s %q, got %s %q", i,
+				tokenTypeToString(exp.Type), exp.Value,
+				tokenTypeToString(tokens[i].Type), tokens[i].Value)
+		}
+	}
+}
+
+func TestControlSymbols(t *testing.T) {
+	input := "50\\% off, \\$5 \\& \\#1\\\\[2pt] next\\\\* Dr.\\ Who\\@."
+
+	tokens := NewLexer(input).Tokenize()
+	for _, token := range tokens {
+		fmt.Printf("Type: %-15s Value: %q\n", tokenTypeToString(token.Type), token.Value)
+	}
+
+	expected := []struct {
+		Type  TokenType
+		Value string
+	}{
+		{TokenText, "50"},
+		{TokenControlSymbol, "%"},
+		{TokenText, " off, "},
+		{TokenControlSymbol, "$"},
+		{TokenText, "5 "},
+		{TokenControlSymbol, "&"},
+		{TokenText, " "},
+		{TokenControlSymbol, "#"},
+		{TokenText, "1"},
+		{TokenControlSymbol, "\\"},
+		{TokenOptionalArg, "2pt"},
+		{TokenText, "next"},
+		{TokenControlSymbol, "\\*"},
+		{TokenText, "Dr."},
+		{TokenControlSymbol, " "},
+		{TokenText, "Who"},
+		{TokenControlSymbol, "@"},
+		{TokenText, "."},
+		{TokenEOF, ""},
+	}
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %d tokens, got %d", len(expected), len(tokens))
+	}
+	for i, exp := range expected {
+		if tokens[i].Type != exp.Type || tokens[i].Value != exp.Value {
+			t.Errorf("Token %d: expected %s %q, got %s %q", i,
+				tokenTypeToString(exp.Type), exp.Value,
+				tokenTypeToString(tokens[i].Type), tokens[i].Value)
+		}
+	}
+}
+
+func TestMathDelimiters(t *testing.T) {
+	input := "\\(a+b\\) and \\[x^2\\]\n\\begin{displaymath}y\\end{displaymath}\\begin{equation*}z\\end{equation*}$c\n\nd"
+
+	tokens := NewLexer(input).Tokenize()
+	for _, token := range tokens {
+		fmt.Printf("Type: %-15s Value: %q Unterminated: %v\n", tokenTypeToString(token.Type), token.Value, token.Unterminated)
+	}
+
+	expected := []struct {
+		Type         TokenType
+		Value        string
+		Unterminated bool
+	}{
+		{TokenMathInline, "a+b", false},
+		{TokenText, "and ", false},
+		{TokenMathDisplay, "x^2", false},
+		{TokenText, "\n", false},
+		{TokenMathDisplay, "y", false},
+		{TokenMathDisplay, "z", false},
+		{TokenMathInline, "c", true},
+		{TokenText, "\n", false},
+		{TokenText, "\n", false},
+		{TokenText, "d", false},
+		{TokenEOF, "", false},
+	}
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %d tokens, got %d", len(expected), len(tokens))
+	}
+	for i, exp := range expected {
+		if tokens[i].Type != exp.Type || tokens[i].Value != exp.Value || tokens[i].Unterminated != exp.Unterminated {
+			t.Errorf("Token %d: expected %s %q (unterminated %v), got %s %q (unterminated %v)", i,
+				tokenTypeToString(exp.Type), exp.Value, exp.Unterminated,
+				tokenTypeToString(tokens[i].Type), tokens[i].Value, tokens[i].Unterminated)
+		}
+	}
+}
+
+func TestInlineMathGroups(t *testing.T) {
+	input := "$\\text{if $y$ ok}$ and $5\\$ … $"
+
+	tokens := NewLexer(input).Tokenize()
+	for _, token := range tokens {
+		fmt.Printf("Type: %-15s Value: %q\n", tokenTypeToString(token.Type), token.Value)
+	}
+
+	expected := []struct {
+		Type  TokenType
+		Value string
+	}{
+		{TokenMathInline, "\\text{if $y$ ok}"},
+		{TokenText, "and "},
+		{TokenMathInline, "5\\$ … "},
+		{TokenEOF, ""},
+	}
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %d tokens, got %d", len(expected), len(tokens))
+	}
+	for i, exp := range expected {
+		if tokens[i].Type != exp.Type || tokens[i].Value != exp.Value {
+			t.Errorf("Token %d: expected %s %q, got %s %q", i,
+				tokenTypeToString(exp.Type), exp.Value, tokenTypeToString(tokens[i].Type), tokens[i].Value)
+		}
+	}
+}
+
+func TestEmptyGroupKeepsSpace(t *testing.T) {
+	input := "\\ldots{} then \\ldots{}."
+
+	tokens := NewLexer(input).Tokenize()
+	for _, token := range tokens {
+		fmt.Printf("Type: %-15s Value: %q\n", tokenTypeToString(token.Type), token.Value)
+	}
+
+	expected := []struct {
+		Type  TokenType
+		Value string
+	}{
+		{TokenCommand, "ldots"},
+		{TokenLBrace, "{"},
+		{TokenRBrace, "}"},
+		{TokenText, " then "},
+		{TokenCommand, "ldots"},
+		{TokenLBrace, "{"},
+		{TokenRBrace, "}"},
+		{TokenText, "."},
+		{TokenEOF, ""},
+	}
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %d tokens, got %d", len(expected), len(tokens))
+	}
+	for i, exp := range expected {
+		if tokens[i].Type != exp.Type || tokens[i].Value != exp.Value {
+			t.Errorf("Token %d: expected %s %q, got %s %q", i,
+				tokenTypeToString(exp.Type), exp.Value,
+				tokenTypeToString(tokens[i].Type), tokens[i].Value)
+		}
+	}
+}
+
+func TestGroupKeepsSpace(t *testing.T) {
+	input := "\\ref{a} and \\textbf{b}. {c}  \nd"
+
+	tokens := NewLexer(input).Tokenize()
+
+	expected := []struct {
+		Type  TokenType
+		Value string
+	}{
+		{TokenCommand, "ref"},
+		{TokenLBrace, "{"},
+		{TokenText, "a"},
+		{TokenRBrace, "}"},
+		{TokenText, " and "},
+		{TokenCommand, "textbf"},
+		{TokenLBrace, "{"},
+		{TokenText, "b"},
+		{TokenRBrace, "}"},
+		{TokenText, ". "},
+		{TokenLBrace, "{"},
+		{TokenText, "c"},
+		{TokenRBrace, "}"},
+		{TokenText, "\n"},
+		{TokenText, "d"},
+		{TokenEOF, ""},
+	}
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %d tokens, got %d", len(expected), len(tokens))
+	}
+	for i, exp := range expected {
+		if tokens[i].Type != exp.Type || tokens[i].Value != exp.Value {
+			t.Errorf("Token %d: expected %s %q, got %s %q", i,
+				tokenTypeToString(exp.Type), exp.Value,
+				tokenTypeToString(tokens[i].Type), tokens[i].Value)
+		}
+	}
+}
+
 func tokenTypeToString(tokenType TokenType) string {
 	switch tokenType {
 	case TokenText:
 		return "TEXT"
 	case TokenCommand:
 		return "COMMAND"
+	case TokenControlSymbol:
+		return "CONTROL_SYMBOL"
 	case TokenBeginEnv:
 		return "BEGIN_ENV"
 	case TokenEndEnv:
@@ -56,6 +349,8 @@ func tokenTypeToString(tokenType TokenType) string {
 		return "OPTIONAL_ARG"
 	case TokenComment:
 		return "COMMENT"
+	case TokenVerbatim:
+		return "VERBATIM"
 	case TokenEOF:
 		return "EOF"
 	default:
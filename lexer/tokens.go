@@ -3,16 +3,18 @@ package lexer
 type TokenType int
 
 const (
-	TokenText        TokenType = iota
-	TokenCommand               // \command
-	TokenBeginEnv              // \begin{env}
-	TokenEndEnv                // \end{env}
-	TokenMathInline            // $...$
-	TokenMathDisplay           // $$...$$
-	TokenLBrace                // {
-	TokenRBrace                // }
-	TokenOptionalArg           // [...]
-	TokenComment               // %...
+	TokenText          TokenType = iota
+	TokenCommand                 // \command
+	TokenBeginEnv                // \begin{env}
+	TokenEndEnv                  // \end{env}
+	TokenMathInline              // $...$
+	TokenMathDisplay             // $$...$$
+	TokenLBrace                  // {
+	TokenRBrace                  // }
+	TokenOptionalArg             // [...]
+	TokenComment                 // %...
+	TokenVerbatim                // raw text of \verb|...| or a verbatim environment body
+	TokenControlSymbol           // \ followed by one non-letter, such as \% or \\
 	TokenEOF
 )
 
@@ -20,6 +22,9 @@ type Token struct {
 	Type  TokenType
 	Value string
 	Pos   Position // Line, Column information
+
+	// Unterminated marks math whose closing delimiter is missing
+	Unterminated bool
 }
 
 type Position struct {
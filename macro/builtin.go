@@ -51,6 +51,21 @@ func (ms *MacroStore) AddBuiltins() {
 			&parser.ArgumentPlaceholder{Index: 0},
 		},
 	}
+
+	ms.macros["emph"] = &Macro{
+		Name:    "emph",
+		NumArgs: 1,
+		Definitions: []parser.Node{
+			&parser.Command{
+				Name: "font",
+				Args: []parser.Node{
+					&parser.TextNode{Value: "emph"},
+				},
+			},
+			// The argument will be inserted here
+			&parser.ArgumentPlaceholder{Index: 0},
+		},
+	}
 }
 
 func (ms *MacroStore) Get(name string) (*Macro, bool) {
@@ -0,0 +1,195 @@
+package math
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rickykimani/gotex/parser"
+)
+
+const (
+	// xHeight is the height of letters without ascenders, such as x, and
+	// capHeight that of capitals and digits, in em. Accents sit just
+	// above letters of x-height and are raised over taller ones.
+	xHeight   = 0.56
+	capHeight = 0.73
+
+	// accentTop is how far a spacing accent such as ˆ reaches above the
+	// baseline it is set on, in em
+	accentTop = 0.8
+
+	// Wide accents are drawn across their base wideAccentGap em above its
+	// top, between wideAccentHeight and maxWideAccentHeight em tall
+	wideAccentGap       = 0.06
+	wideAccentHeight    = 0.18
+	maxWideAccentHeight = 0.3
+)
+
+// xHeightLetters are the letters that reach no higher than x
+const xHeightLetters = "acegmnopqrsuvwxyzıȷαγεηικμνοπρστυφχψωϵ"
+
+// smallAccents are accents set from a glyph that is not a spacing accent,
+// such as the arrow of \vec, with the scale it is set at and how far it is
+// raised, in em, to sit where the spacing accents do
+var smallAccents = map[string]struct{ scale, raise float64 }{
+	"→": {scale: 0.6, raise: 0.56},
+}
+
+// fontSlants are how far the letters of slanted fonts lean, as the run
+// over the rise of their italic angle
+var fontSlants = map[string]float64{
+	"italic":           0.176,
+	"bold-italic":      0.176,
+	"math-italic":      0.194,
+	"math-bold-italic": 0.194,
+}
+
+// glyphHeight returns how far a character reaches above its baseline, in
+// em
+func glyphHeight(r rune) float64 {
+	switch {
+	case strings.ContainsRune(xHeightLetters, r):
+		return xHeight
+	case r == 't':
+		return 0.7
+	case unicode.IsUpper(r) || unicode.IsDigit(r):
+		return capHeight
+	}
+	return textHeight
+}
+
+// singleCharacter returns the character of a base that is a single
+// character, such as x or \alpha
+func singleCharacter(base parser.Node) (string, bool) {
+	var text string
+	switch n := base.(type) {
+	case *parser.TextNode:
+		text = strings.TrimSpace(n.Value)
+	case *parser.MathSymbol:
+		text = n.Symbol
+	case *parser.Group:
+		if len(n.Nodes) == 1 {
+			return singleCharacter(n.Nodes[0])
+		}
+		return "", false
+	default:
+		return "", false
+	}
+	return text, text != "" && utf8.RuneCountInString(text) == 1
+}
+
+// baseHeight returns the real height of the base of an accent: that of its
+// tallest glyph for letters and symbols, so an accent sits lower over x
+// than over b, and otherwise how far it reaches
+func (m *MathProcessor) baseHeight(base parser.Node, style mathStyle) float64 {
+	switch n := base.(type) {
+	case *parser.TextNode:
+		height := 0.0
+		for _, r := range strings.TrimSpace(n.Value) {
+			height = math.Max(height, glyphHeight(r))
+		}
+		return height * m.size(style)
+	case *parser.MathSymbol:
+		if _, ok := largeOperator(n); !ok {
+			r, _ := utf8.DecodeRuneInString(n.Symbol)
+			return glyphHeight(r) * m.size(style)
+		}
+	case *parser.Group:
+		height := 0.0
+		for _, node := range n.Nodes {
+			height = math.Max(height, m.baseHeight(node, style))
+		}
+		return height
+	}
+	height, _ := m.elementExtent(base, style)
+	return height
+}
+
+// accentSkew returns how far an accent is moved right to sit over the top
+// of a slanted letter rather than the middle of its box
+func (m *MathProcessor) accentSkew(base parser.Node, height float64) float64 {
+	char, ok := singleCharacter(base)
+	if !ok {
+		return 0
+	}
+	return fontSlants[GetMathFont(char)] * height / 2
+}
+
+// accentRaise returns how far an accent is raised over a base of a height
+// set in a style: by as much as the base is taller than x
+func (m *MathProcessor) accentRaise(height float64, style mathStyle) float64 {
+	return height - math.Min(height, xHeight*m.size(style))
+}
+
+// wideAccentSize returns the height of a wide accent over a base of a
+// width, which grows with the width up to a limit
+func (m *MathProcessor) wideAccentSize(width float64, style mathStyle) float64 {
+	fontSize := m.size(style)
+	return math.Min(math.Max(0.1*width, wideAccentHeight*fontSize), maxWideAccentHeight*fontSize)
+}
+
+// renderAccent renders math with an accent centered over it. The base is
+// set in the cramped form of the style and keeps its width.
+func (m *MathProcessor) renderAccent(accent *parser.MathAccent, x, y float64, style mathStyle) float64 {
+	baseStyle := style.cramp()
+	fontSize := m.size(style)
+	width := m.renderMathElement(accent.Base, x, y, baseStyle)
+	height := m.baseHeight(accent.Base, baseStyle)
+	skew := m.accentSkew(accent.Base, height)
+
+	if accent.Wide {
+		bottom := y + math.Max(height, xHeight*fontSize) + wideAccentGap*fontSize
+		m.renderWideAccent(accent.Accent, x+skew, bottom, width, m.wideAccentSize(width, style))
+		return width
+	}
+
+	glyphSize, raise := fontSize, m.accentRaise(height, style)
+	if small, ok := smallAccents[accent.Accent]; ok {
+		glyphSize, raise = small.scale*fontSize, raise+small.raise*fontSize
+	}
+	accentWidth := m.generator.GetTextWidth(accent.Accent, glyphSize, "normal")
+	m.generator.AddText(accent.Accent, x+(width-accentWidth)/2+skew, y+raise, glyphSize, "normal")
+	return width
+}
+
+// renderWideAccent draws a hat or tilde across a width, from a bottom up
+// to a height
+func (m *MathProcessor) renderWideAccent(accent string, x, bottom, width, height float64) {
+	if accent == "ˆ" {
+		m.generator.AddLine(x, bottom, x+width/2, bottom+height)
+		m.generator.AddLine(x+width/2, bottom+height, x+width, bottom)
+		return
+	}
+
+	// A tilde rises from its low left end to a crest, falls to a trough
+	// and rises again to its right end
+	const segments = 24
+	middle := bottom + height/2
+	point := func(i int) (float64, float64) {
+		t := float64(i) / segments
+		return x + t*width, middle - height/2*math.Cos(3*math.Pi*t)
+	}
+	for i := range segments {
+		x1, y1 := point(i)
+		x2, y2 := point(i + 1)
+		m.generator.AddLine(x1, y1, x2, y2)
+	}
+}
+
+// accentExtent returns how far math with an accent reaches above and below
+// its baseline
+func (m *MathProcessor) accentExtent(accent *parser.MathAccent, style mathStyle) (float64, float64) {
+	baseStyle := style.cramp()
+	fontSize := m.size(style)
+	baseHeight, depth := m.elementExtent(accent.Base, baseStyle)
+	height := m.baseHeight(accent.Base, baseStyle)
+
+	top := m.accentRaise(height, style) + accentTop*fontSize
+	if accent.Wide {
+		width := m.calculateElementWidth(accent.Base, baseStyle)
+		top = math.Max(height, xHeight*fontSize) + wideAccentGap*fontSize + m.wideAccentSize(width, style)
+	}
+	return math.Max(baseHeight, top), depth
+}
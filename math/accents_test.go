@@ -0,0 +1,30 @@
+package math
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rickykimani/gotex/parser"
+)
+
+func TestAccentRaise(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     parser.Node
+		expected float64 // In em
+	}{
+		{"x-height letter", &parser.TextNode{Value: "x"}, 0},
+		{"letter with an ascender", &parser.TextNode{Value: "b"}, textHeight - xHeight},
+		{"capital", &parser.TextNode{Value: "A"}, capHeight - xHeight},
+		{"x-height Greek letter", &parser.MathSymbol{Symbol: "α", Command: "alpha"}, 0},
+		{"tallest letter of a run", &parser.Group{Nodes: []parser.Node{&parser.TextNode{Value: "ab"}}}, textHeight - xHeight},
+	}
+
+	m := &MathProcessor{fontSize: 10}
+	for _, tt := range tests {
+		got := m.accentRaise(m.baseHeight(tt.base, textStyle), textStyle)
+		if math.Abs(got-tt.expected*10) > 1e-9 {
+			t.Errorf("%s: expected a raise of %g, got %g", tt.name, tt.expected*10, got)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package math
+
+import (
+	"strings"
+	"unicode"
+)
+
+// mathAlphabet is the alphabet set by a command such as \mathbb: where its
+// letters and digits are in the Mathematical Alphanumeric Symbols block,
+// the letters that are in the Letterlike Symbols block instead, the font
+// style those characters are set in, and the style the plain characters
+// are set in where that font has no glyph for them
+type mathAlphabet struct {
+	upper, lower, digits   rune // The first of each range, or 0 for none
+	greekUpper, greekLower rune
+	letterlike             map[rune]rune
+	font                   string // The style of the alphabet's characters, if not "normal"
+	style                  string
+	italicStyle            string // The style of letters that are otherwise italic, if not style
+	symbols                bool   // Whether symbols other than letters take the style too
+}
+
+// boldAlphabet is the bold alphabet, the only one with Greek letters
+var boldAlphabet = mathAlphabet{
+	upper: 0x1D400, lower: 0x1D41A, digits: 0x1D7CE,
+	greekUpper: 0x1D6A8, greekLower: 0x1D6C2,
+	style: "math-bold",
+}
+
+// mathAlphabets are the commands that set their argument in an alphabet.
+// Double-struck and sans-serif letters are in the math font, and script
+// and fraktur letters in the math alphabet font. Bold has no glyphs in the
+// math font, so it is set in the bold face, and typewriter letters in the
+// typewriter face. \boldsymbol and \bm also set symbols in
+// bold, keeping variables italic. \mathrm sets letters upright and
+// \mathit sets digits and capital Greek letters in italic too.
+var mathAlphabets = map[string]mathAlphabet{
+	"mathbf":     boldAlphabet,
+	"boldsymbol": boldAlphabet.withSymbols(),
+	"bm":         boldAlphabet.withSymbols(),
+	"mathcal": {
+		upper: 0x1D49C, lower: 0x1D4B6,
+		letterlike: map[rune]rune{
+			'B': 'ℬ', 'E': 'ℰ', 'F': 'ℱ', 'H': 'ℋ', 'I': 'ℐ', 'L': 'ℒ', 'M': 'ℳ', 'R': 'ℛ',
+			'e': 'ℯ', 'g': 'ℊ', 'o': 'ℴ',
+		},
+		font: "math-alphabet",
+	},
+	"mathfrak": {
+		upper: 0x1D504, lower: 0x1D51E,
+		letterlike: map[rune]rune{'C': 'ℭ', 'H': 'ℌ', 'I': 'ℑ', 'R': 'ℜ', 'Z': 'ℨ'},
+		font:       "math-alphabet",
+	},
+	"mathbb": {
+		upper: 0x1D538, lower: 0x1D552, digits: 0x1D7D8,
+		letterlike: map[rune]rune{'C': 'ℂ', 'H': 'ℍ', 'N': 'ℕ', 'P': 'ℙ', 'Q': 'ℚ', 'R': 'ℝ', 'Z': 'ℤ'},
+		style:      "math-bold",
+	},
+	"mathsf": {upper: 0x1D5A0, lower: 0x1D5BA, digits: 0x1D7E2, style: "normal"},
+	"mathtt": {upper: 0x1D670, lower: 0x1D68A, digits: 0x1D7F6, style: "mono"},
+	"mathrm": {style: "normal"},
+	"mathit": {style: "math-italic"},
+}
+
+// withSymbols returns the alphabet setting symbols in its style too, and
+// italic letters in bold italic
+func (a mathAlphabet) withSymbols() mathAlphabet {
+	a.symbols = true
+	a.italicStyle = "math-bold-italic"
+	return a
+}
+
+// char returns the character of the alphabet that r is set as, which is r
+// itself where the alphabet has none
+func (a *mathAlphabet) char(r rune) rune {
+	if c, ok := a.letterlike[r]; ok {
+		return c
+	}
+	switch {
+	case r >= 'A' && r <= 'Z' && a.upper != 0:
+		return a.upper + r - 'A'
+	case r >= 'a' && r <= 'z' && a.lower != 0:
+		return a.lower + r - 'a'
+	case r >= '0' && r <= '9' && a.digits != 0:
+		return a.digits + r - '0'
+	case r >= 'Α' && r <= 'Ω' && a.greekUpper != 0:
+		return a.greekUpper + r - 'Α'
+	case r >= 'α' && r <= 'ω' && a.greekLower != 0:
+		return a.greekLower + r - 'α'
+	}
+	return r
+}
+
+// charFont returns the style the alphabet's characters are set in
+func (a *mathAlphabet) charFont() string {
+	if a.font == "" {
+		return "normal"
+	}
+	return a.font
+}
+
+// inAlphabet calls f with the math it sets or measures in the alphabet of
+// a command, returning the width f does
+func (m *MathProcessor) inAlphabet(command string, f func() float64) float64 {
+	alphabet := mathAlphabets[command]
+	saved := m.alphabet
+	m.alphabet = &alphabet
+	defer func() { m.alphabet = saved }()
+	return f()
+}
+
+// textRun is math text set in one font style
+type textRun struct {
+	text, style string
+}
+
+// textRuns splits math text into the runs it is set as, each character in
+// the style GetMathFont gives it. In an alphabet, letters and digits
+// become the alphabet's characters where its font has glyphs for them and
+// are otherwise set in the alphabet's style.
+func (m *MathProcessor) textRuns(text string) []textRun {
+	var runs []textRun
+	for _, r := range text {
+		char, style := r, GetMathFont(string(r))
+		if a := m.alphabet; a != nil {
+			inAlphabet := unicode.IsLetter(r) || unicode.IsDigit(r) || a.symbols
+			alphabetStyle := a.style
+			if a.italicStyle != "" && style == "math-italic" {
+				alphabetStyle = a.italicStyle
+			}
+			if c := a.char(r); c != r && m.generator.HasGlyph(c, a.charFont()) {
+				char, style = c, a.charFont()
+			} else if inAlphabet && alphabetStyle != "" && m.generator.HasGlyph(r, alphabetStyle) {
+				style = alphabetStyle
+			}
+		}
+
+		if n := len(runs); n > 0 && runs[n-1].style == style {
+			runs[n-1].text += string(char)
+		} else {
+			runs = append(runs, textRun{text: string(char), style: style})
+		}
+	}
+	return runs
+}
+
+// mathTextWidth returns the width of math text set at a size
+func (m *MathProcessor) mathTextWidth(text string, fontSize float64) float64 {
+	width := 0.0
+	for _, run := range m.textRuns(strings.TrimSpace(text)) {
+		width += m.generator.GetTextWidth(run.text, fontSize, run.style)
+	}
+	return width
+}
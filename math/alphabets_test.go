@@ -0,0 +1,65 @@
+package math
+
+import (
+	"testing"
+
+	"github.com/rickykimani/gotex/pdf"
+	"github.com/rickykimani/gotex/ttf"
+)
+
+func TestAlphabetChars(t *testing.T) {
+	tests := []struct {
+		command  string
+		char     rune
+		expected rune
+	}{
+		{"mathbb", 'A', '𝔸'},
+		{"mathbb", 'R', 'ℝ'},
+		{"mathbb", 'k', '𝕜'},
+		{"mathbb", '1', '𝟙'},
+		{"mathcal", 'A', '𝒜'},
+		{"mathcal", 'H', 'ℋ'},
+		{"mathfrak", 'g', '𝔤'},
+		{"mathfrak", 'R', 'ℜ'},
+		{"mathbf", 'x', '𝐱'},
+		{"mathbf", 'Γ', '𝚪'},
+		{"boldsymbol", 'ω', '𝛚'},
+		{"mathsf", 'S', '𝖲'},
+		{"mathtt", '0', '𝟶'},
+		{"mathrm", 'd', 'd'},
+		{"mathbb", '+', '+'},
+	}
+
+	for _, tt := range tests {
+		alphabet := mathAlphabets[tt.command]
+		if got := alphabet.char(tt.char); got != tt.expected {
+			t.Errorf("\\%s{%c}: expected %c (U+%04X), got %c (U+%04X)", tt.command, tt.char, tt.expected, tt.expected, got, got)
+		}
+	}
+}
+
+func TestAlphabetGlyphs(t *testing.T) {
+	generator, err := pdf.NewGenerator(ttf.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMathProcessor(generator, 12)
+
+	// Every letter of these alphabets is set as the alphabet's character,
+	// never as the plain letter
+	for _, command := range []string{"mathcal", "mathfrak", "mathbb", "mathsf"} {
+		alphabet := mathAlphabets[command]
+		for _, r := range "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz" {
+			m.alphabet = &alphabet
+			runs := m.textRuns(string(r))
+			m.alphabet = nil
+			if len(runs) != 1 || runs[0].text != string(alphabet.char(r)) {
+				t.Errorf("\\%s{%c}: expected %c, got %v", command, r, alphabet.char(r), runs)
+				continue
+			}
+			if !generator.HasGlyph(alphabet.char(r), runs[0].style) {
+				t.Errorf("\\%s{%c}: %s has no glyph for %c", command, r, runs[0].style, alphabet.char(r))
+			}
+		}
+	}
+}
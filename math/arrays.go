@@ -0,0 +1,230 @@
+package math
+
+import (
+	"math"
+
+	"github.com/rickykimani/gotex/parser"
+)
+
+// arrayLayout is how a math environment such as pmatrix sets its rows and
+// columns
+type arrayLayout struct {
+	// left and right are the delimiters around the environment, which grow
+	// to its height
+	left, right string
+
+	// columns holds the alignment of each column, l, c or r, repeated for
+	// as many columns as there are
+	columns string
+
+	// columnSep is the space between columns in em, and stretch how much
+	// the rows are spread apart
+	columnSep float64
+	stretch   float64
+
+	// paired layouts such as aligned set their columns in pairs, the first
+	// right and the second left aligned, with columnSep only between pairs
+	paired bool
+
+	// style is the style the cells are set in, which is text style in
+	// display math as well
+	style mathStyle
+}
+
+// arrayLayouts are the math environments set in rows and columns
+var arrayLayouts = map[string]arrayLayout{
+	"matrix":      {columns: "c", columnSep: 1, stretch: 1, style: textStyle},
+	"pmatrix":     {left: "(", right: ")", columns: "c", columnSep: 1, stretch: 1, style: textStyle},
+	"bmatrix":     {left: "[", right: "]", columns: "c", columnSep: 1, stretch: 1, style: textStyle},
+	"Bmatrix":     {left: "{", right: "}", columns: "c", columnSep: 1, stretch: 1, style: textStyle},
+	"vmatrix":     {left: "|", right: "|", columns: "c", columnSep: 1, stretch: 1, style: textStyle},
+	"Vmatrix":     {left: "‖", right: "‖", columns: "c", columnSep: 1, stretch: 1, style: textStyle},
+	"smallmatrix": {columns: "c", columnSep: 0.5, stretch: 0.7, style: scriptStyle},
+	"cases":       {left: "{", columns: "l", columnSep: 1, stretch: 1.2, style: textStyle},
+	"aligned":     {columns: "rl", columnSep: 2, stretch: 1.2, paired: true, style: displayStyle},
+	"split":       {columns: "rl", columnSep: 2, stretch: 1.2, paired: true, style: displayStyle},
+	"gathered":    {columns: "c", stretch: 1.2, style: displayStyle},
+
+	// The lines of align, gather and multline are set by the document
+	"align":    {columns: "rl", columnSep: 2, stretch: 1, paired: true, style: displayStyle},
+	"gather":   {columns: "c", stretch: 1, style: displayStyle},
+	"multline": {columns: "c", stretch: 1, style: displayStyle},
+}
+
+const (
+	// arrayStrutHeight and arrayStrutDepth are the least height and depth
+	// of a row, in em, so rows of short cells are a line apart
+	arrayStrutHeight = 0.84
+	arrayStrutDepth  = 0.36
+)
+
+// layoutOf returns the layout of a math environment. Unknown environments
+// are set as a matrix.
+func layoutOf(array *parser.MathArray) arrayLayout {
+	if layout, ok := arrayLayouts[array.Name]; ok {
+		return layout
+	}
+	return arrayLayouts["matrix"]
+}
+
+// cellStyle returns the style the cells of an array are set in within a
+// style, which is never larger than the style around the array
+func (l arrayLayout) cellStyle(style mathStyle) mathStyle {
+	if l.style > style {
+		return l.style | style%2
+	}
+	return style
+}
+
+// arrayGrid is the measured layout of an array: the width of each column,
+// the height and depth of each row and the size of the whole, which is
+// centered on the math axis
+type arrayGrid struct {
+	layout               arrayLayout
+	style                mathStyle // The style of the cells
+	rows                 [][]parser.Node
+	cellWidths           [][]float64
+	widths               []float64
+	heights, depths      []float64
+	columnSep            float64
+	width, height, depth float64
+}
+
+// gapAfter returns the space after column j
+func (g arrayGrid) gapAfter(j int) float64 {
+	if g.layout.paired && j%2 == 0 {
+		return 0
+	}
+	return g.columnSep
+}
+
+// measureArray measures the rows and columns of an array set in a style.
+// The array is centered on the math axis.
+func (m *MathProcessor) measureArray(array *parser.MathArray, style mathStyle) arrayGrid {
+	layout := layoutOf(array)
+	cellStyle := layout.cellStyle(style)
+	cellSize := m.size(cellStyle)
+	total := 0.0
+	g := arrayGrid{
+		layout:    layout,
+		rows:      array.Rows,
+		style:     cellStyle,
+		columnSep: layout.columnSep * m.size(style),
+	}
+	if layout.paired {
+		g.rows = pairedRows(array.Rows)
+	}
+
+	for _, row := range g.rows {
+		height := arrayStrutHeight * layout.stretch * cellSize
+		depth := arrayStrutDepth * layout.stretch * cellSize
+		widths := make([]float64, len(row))
+		for j, cell := range row {
+			h, d := m.elementExtent(cell, cellStyle)
+			height, depth = math.Max(height, h), math.Max(depth, d)
+			widths[j] = m.calculateElementWidth(cell, cellStyle)
+			if j >= len(g.widths) {
+				g.widths = append(g.widths, 0)
+			}
+			g.widths[j] = math.Max(g.widths[j], widths[j])
+		}
+		g.heights = append(g.heights, height)
+		g.depths = append(g.depths, depth)
+		g.cellWidths = append(g.cellWidths, widths)
+		total += height + depth
+	}
+
+	for j, width := range g.widths {
+		if j > 0 {
+			g.width += g.gapAfter(j - 1)
+		}
+		g.width += width
+	}
+	axis := mathAxis * m.size(style)
+	g.height, g.depth = axis+total/2, total/2-axis
+	return g
+}
+
+// renderArray renders a math environment such as pmatrix or cases, with
+// its delimiters
+func (m *MathProcessor) renderArray(array *parser.MathArray, x, y float64, style mathStyle) float64 {
+	g := m.measureArray(array, style)
+	cover := m.delimiterCover(g.height, g.depth, style)
+
+	currentX := x
+	if g.layout.left != "" {
+		currentX += m.renderDelimiter(g.layout.left, currentX, y, cover, style)
+	}
+
+	// Rows are set down from the top of the array, and cells aligned in
+	// their columns
+	baseline := y + g.height
+	for i := range g.rows {
+		baseline -= g.heights[i]
+		m.renderRow(g, i, currentX, baseline)
+		baseline -= g.depths[i]
+	}
+	currentX += g.width
+
+	if g.layout.right != "" {
+		currentX += m.renderDelimiter(g.layout.right, currentX, y, cover, style)
+	}
+	return currentX - x
+}
+
+// renderRow renders row i of an array from x, with its cells aligned in
+// their columns
+func (m *MathProcessor) renderRow(g arrayGrid, i int, x, baseline float64) {
+	for j, cell := range g.rows[i] {
+		cellX := x
+		switch g.layout.columns[j%len(g.layout.columns)] {
+		case 'c':
+			cellX += (g.widths[j] - g.cellWidths[i][j]) / 2
+		case 'r':
+			cellX += g.widths[j] - g.cellWidths[i][j]
+		}
+		m.renderMathElement(cell, cellX, baseline, g.style)
+		x += g.widths[j] + g.gapAfter(j)
+	}
+}
+
+// pairedRows returns the rows of a paired layout with an empty group
+// before each left-aligned cell, so a relation at its start, as in
+// x &= y, is spaced as though it followed the cell before it
+func pairedRows(rows [][]parser.Node) [][]parser.Node {
+	paired := make([][]parser.Node, len(rows))
+	for i, row := range rows {
+		paired[i] = make([]parser.Node, len(row))
+		for j, cell := range row {
+			if group, ok := cell.(*parser.Group); ok && j%2 == 1 {
+				nodes := append([]parser.Node{&parser.Group{}}, group.Nodes...)
+				cell = &parser.Group{Nodes: nodes, Position: group.Position}
+			}
+			paired[i][j] = cell
+		}
+	}
+	return paired
+}
+
+// arrayWidth calculates the width of a math environment such as pmatrix
+func (m *MathProcessor) arrayWidth(array *parser.MathArray, style mathStyle) float64 {
+	g := m.measureArray(array, style)
+	cover := m.delimiterCover(g.height, g.depth, style)
+
+	width := g.width
+	if g.layout.left != "" {
+		width += m.delimiterWidth(g.layout.left, cover, style)
+	}
+	if g.layout.right != "" {
+		width += m.delimiterWidth(g.layout.right, cover, style)
+	}
+	return width
+}
+
+// arrayExtent returns how far a math environment such as pmatrix reaches
+// above and below its baseline, taking in its delimiters
+func (m *MathProcessor) arrayExtent(array *parser.MathArray, style mathStyle) (float64, float64) {
+	g := m.measureArray(array, style)
+	cover := m.delimiterCover(g.height, g.depth, style)
+	return m.delimitersExtent(g.height, g.depth, cover, style, g.layout.left, g.layout.right)
+}
@@ -0,0 +1,58 @@
+package math
+
+import (
+	"testing"
+
+	"github.com/rickykimani/gotex/parser"
+)
+
+func TestArrayCellStyle(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		style    mathStyle
+		expected mathStyle
+	}{
+		{"matrix in display", "pmatrix", displayStyle, textStyle},
+		{"matrix in cramped display", "bmatrix", displayCramped, textCramped},
+		{"matrix in script", "matrix", scriptStyle, scriptStyle},
+		{"small matrix in text", "smallmatrix", textStyle, scriptStyle},
+		{"unknown environment", "foo", displayStyle, textStyle},
+	}
+
+	for _, tt := range tests {
+		layout := layoutOf(&parser.MathArray{Name: tt.env})
+		if got := layout.cellStyle(tt.style); got != tt.expected {
+			t.Errorf("%s: expected style %d, got %d", tt.name, tt.expected, got)
+		}
+	}
+
+	if layout := layoutOf(&parser.MathArray{Name: "cases"}); layout.left != "{" || layout.right != "" || layout.columns != "l" {
+		t.Errorf("Expected cases to be left aligned with a brace on the left, got %+v", layout)
+	}
+}
+
+func TestPairedRows(t *testing.T) {
+	cell := func(nodes ...parser.Node) parser.Node { return &parser.Group{Nodes: nodes} }
+	rows := pairedRows([][]parser.Node{{
+		cell(&parser.TextNode{Value: "x"}),
+		cell(&parser.TextNode{Value: "=y"}),
+		cell(&parser.TextNode{Value: "z"}),
+	}})
+
+	for j, expected := range []int{1, 2, 1} {
+		if got := len(rows[0][j].(*parser.Group).Nodes); got != expected {
+			t.Errorf("Cell %d: expected %d nodes, got %d", j, expected, got)
+		}
+	}
+	if first := rows[0][1].(*parser.Group).Nodes[0].(*parser.Group); len(first.Nodes) != 0 {
+		t.Errorf("Expected an empty group before the left-aligned cell, got %#v", first)
+	}
+
+	g := arrayGrid{layout: arrayLayouts["aligned"], columnSep: 2}
+	for j, expected := range []float64{0, 2, 0} {
+		if got := g.gapAfter(j); got != expected {
+			t.Errorf("Column %d: expected a gap of %g, got %g", j, expected, got)
+		}
+	}
+}
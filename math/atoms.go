@@ -0,0 +1,175 @@
+package math
+
+import (
+	"slices"
+
+	"github.com/rickykimani/gotex/parser"
+	"github.com/rickykimani/gotex/symbols"
+)
+
+// atomClass is the class of a math atom, which decides the space between
+// it and its neighbours
+type atomClass int
+
+const (
+	ordAtom   atomClass = iota // variables, numbers and ordinary symbols
+	opAtom                     // large operators and operator names
+	binAtom                    // binary operations such as + and \times
+	relAtom                    // relations such as = and \le
+	openAtom                   // opening delimiters
+	closeAtom                  // closing delimiters
+	punctAtom                  // punctuation
+	innerAtom                  // fractions
+)
+
+// atom is an element of a math list with its class and the space before
+// it. Kerns are explicit spaces such as \, which are not atoms, so they
+// leave the space between the atoms around them alone.
+type atom struct {
+	node  parser.Node
+	class atomClass
+	kern  bool
+	space float64
+	style mathStyle
+}
+
+var (
+	openings    = []string{"(", "[", "{", "⟨", "⌈", "⌊"}
+	closings    = []string{")", "]", "}", "⟩", "⌉", "⌋", "!", "?"}
+	punctuation = []string{",", ";"}
+)
+
+// symbolClass returns the class of a math character or symbol
+func symbolClass(s string) atomClass {
+	switch {
+	case IsOperator(s):
+		return binAtom
+	case IsRelation(s):
+		return relAtom
+	case isLargeOperator(s):
+		return opAtom
+	case slices.Contains(openings, s):
+		return openAtom
+	case slices.Contains(closings, s):
+		return closeAtom
+	case slices.Contains(punctuation, s):
+		return punctAtom
+	}
+	return ordAtom
+}
+
+// nodeClass returns the class of a math node. Scripts take the class of
+// their base, and braced groups are ordinary.
+func (m *MathProcessor) nodeClass(node parser.Node) atomClass {
+	switch n := node.(type) {
+	case *parser.TextNode:
+		return symbolClass(n.Value)
+	case *parser.MathSymbol:
+		return symbolClass(n.Symbol)
+	case *parser.Command:
+		if _, ok := m.lookupOperator(n); ok {
+			return opAtom
+		}
+		if symbol, ok := symbols.ConvertMathSymbol(n.Name); ok {
+			return symbolClass(symbol)
+		}
+		if _, _, ok := commandFraction(n, textStyle); ok {
+			return innerAtom
+		}
+		if limitsCommands[n.Name] && len(n.Args) > 0 {
+			return m.nodeClass(n.Args[0])
+		}
+		if _, ok := bigDelimiterScale(n.Name); ok {
+			return bigDelimiterClass(n.Name)
+		}
+	case *parser.MathFraction, *parser.MathDelimited:
+		return innerAtom
+	case *parser.MathArray:
+		// Environments with delimiters are set like \left and \right
+		if layout := layoutOf(n); layout.left != "" || layout.right != "" {
+			return innerAtom
+		}
+	case *parser.MathOverUnder:
+		return m.overUnderClass(n)
+	case *parser.MathSuperscript:
+		if n.Base != nil {
+			return m.nodeClass(n.Base)
+		}
+	case *parser.MathSubscript:
+		if n.Base != nil {
+			return m.nodeClass(n.Base)
+		}
+	}
+	return ordAtom
+}
+
+// atoms splits a math list set in a style into atoms and sets the space
+// before each. A binary operation with nothing to operate on, as in -x or
+// a = -b, is ordinary. Spaces that only appear between larger atoms are
+// left out in script styles, and an italic letter is followed by its
+// italic correction before upright math. Style commands such as
+// \scriptstyle change the style of the atoms after them.
+func (m *MathProcessor) atoms(nodes []parser.Node, style mathStyle) []atom {
+	var atoms []atom
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *parser.TextNode:
+			for _, a := range textAtoms(n) {
+				a.style = style
+				atoms = append(atoms, a)
+			}
+		case *parser.Command:
+			if target, ok := styleCommands[n.Name]; ok {
+				style = style.switchTo(target)
+				atoms = append(atoms, atom{node: n, kern: true, style: style})
+				continue
+			}
+			_, kern := explicitSpaces[n.Name]
+			atoms = append(atoms, atom{node: n, class: m.nodeClass(n), kern: kern, style: style})
+		default:
+			atoms = append(atoms, atom{node: n, class: m.nodeClass(n), style: style})
+		}
+	}
+
+	prev := -1
+	for i := range atoms {
+		if atoms[i].kern {
+			continue
+		}
+		switch atoms[i].class {
+		case binAtom:
+			if prev < 0 {
+				atoms[i].class = ordAtom
+			} else {
+				switch atoms[prev].class {
+				case binAtom, opAtom, relAtom, openAtom, punctAtom:
+					atoms[i].class = ordAtom
+				}
+			}
+		case relAtom, closeAtom, punctAtom:
+			if prev >= 0 && atoms[prev].class == binAtom {
+				atoms[prev].class = ordAtom
+			}
+		}
+		prev = i
+	}
+	if prev >= 0 && atoms[prev].class == binAtom {
+		atoms[prev].class = ordAtom
+	}
+
+	prev = -1
+	for i := range atoms {
+		if atoms[i].kern {
+			continue
+		}
+		if prev >= 0 {
+			s := atoms[i].style
+			atoms[i].space = m.spacing.between(atoms[prev].class, atoms[i].class, s.isScript()) * m.size(s)
+			if !m.startsItalic(atoms[i].node) {
+				atoms[i].space += m.italicCorrection(atoms[prev].node, atoms[prev].style)
+			}
+		}
+		prev = i
+	}
+	return atoms
+}
@@ -0,0 +1,40 @@
+package math
+
+import (
+	"testing"
+
+	"github.com/rickykimani/gotex/lexer"
+	"github.com/rickykimani/gotex/parser"
+)
+
+func TestAtomClasses(t *testing.T) {
+	m := &MathProcessor{fontSize: 12, spacing: StandardMathSpacing()}
+
+	tests := []struct {
+		input   string
+		classes []atomClass
+		spaces  []float64 // in em
+	}{
+		{"a+b=c", []atomClass{ordAtom, binAtom, ordAtom, relAtom, ordAtom}, []float64{0, 4.0 / 18, 4.0 / 18, 5.0 / 18, 5.0 / 18}},
+		{"-x", []atomClass{ordAtom, ordAtom}, []float64{0, 0}},
+		{"a=-b", []atomClass{ordAtom, relAtom, ordAtom, ordAtom}, []float64{0, 5.0 / 18, 5.0 / 18, 0}},
+		{"f(x, y)", []atomClass{ordAtom, openAtom, ordAtom, punctAtom, ordAtom, closeAtom}, []float64{0, 0.13, 0, 0.01, 3.0 / 18, 0.01}},
+		{"2xy", []atomClass{ordAtom, ordAtom, ordAtom}, []float64{0, 0, 0}},
+		{`\sin x`, []atomClass{opAtom, ordAtom}, []float64{0, 3.0 / 18}},
+		{`a+\,b`, []atomClass{ordAtom, binAtom, ordAtom, ordAtom}, []float64{0, 4.0 / 18, 0, 4.0 / 18}},
+	}
+
+	for _, tt := range tests {
+		atoms := m.atoms(parser.ParseMath(tt.input, true, lexer.Position{}).Content, textStyle)
+		if len(atoms) != len(tt.classes) {
+			t.Errorf("%s: expected %d atoms, got %d", tt.input, len(tt.classes), len(atoms))
+			continue
+		}
+		for i, a := range atoms {
+			if a.class != tt.classes[i] || a.space != tt.spaces[i]*12 {
+				t.Errorf("%s: atom %d: expected class %d after %.2fpt, got class %d after %.2fpt",
+					tt.input, i, tt.classes[i], tt.spaces[i]*12, a.class, a.space)
+			}
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package math
+
+import (
+	"math"
+	"strings"
+
+	"github.com/rickykimani/gotex/parser"
+)
+
+// delimiterScales are the sizes a delimiter glyph is set at relative to
+// the font size of its style: normal, then those of \big, \Big, \bigg and
+// \Bigg
+var delimiterScales = []float64{1, 1.2, 1.8, 2.4, 3.0}
+
+// bigDelimiters are the commands that set a delimiter at a fixed size, as
+// an index into delimiterScales
+var bigDelimiters = map[string]int{
+	"big":  1,
+	"Big":  2,
+	"bigg": 3,
+	"Bigg": 4,
+}
+
+// delimiterPieces are the glyphs a delimiter taller than \Bigg is built
+// from: a top and bottom, an extender repeated between them, and for
+// braces a middle. The pieces reach ascent above and descent below their
+// baseline, in em.
+type delimiterPieces struct {
+	top, extender, middle, bottom string
+	ascent, descent               float64
+}
+
+// extensibleDelimiters are the delimiters that can be built from pieces.
+// Others, such as angle brackets, are scaled further.
+var extensibleDelimiters = map[string]delimiterPieces{
+	"(": {top: "⎛", extender: "⎜", bottom: "⎝", ascent: 0.94, descent: 0.25},
+	")": {top: "⎞", extender: "⎟", bottom: "⎠", ascent: 0.94, descent: 0.25},
+	"[": {top: "⎡", extender: "⎢", bottom: "⎣", ascent: 0.94, descent: 0.25},
+	"]": {top: "⎤", extender: "⎥", bottom: "⎦", ascent: 0.94, descent: 0.25},
+	"⌈": {top: "⎡", extender: "⎢", bottom: "⎢", ascent: 0.94, descent: 0.25},
+	"⌉": {top: "⎤", extender: "⎥", bottom: "⎥", ascent: 0.94, descent: 0.25},
+	"⌊": {top: "⎢", extender: "⎢", bottom: "⎣", ascent: 0.94, descent: 0.25},
+	"⌋": {top: "⎥", extender: "⎥", bottom: "⎦", ascent: 0.94, descent: 0.25},
+	"{": {top: "⎧", extender: "⎪", middle: "⎨", bottom: "⎩", ascent: 0.94, descent: 0.25},
+	"}": {top: "⎫", extender: "⎪", middle: "⎬", bottom: "⎭", ascent: 0.94, descent: 0.25},
+	"|": {top: "|", extender: "|", bottom: "|", ascent: 0.76, descent: 0.23},
+	"‖": {top: "‖", extender: "‖", bottom: "‖", ascent: 0.76, descent: 0.23},
+}
+
+const (
+	// delimiterAscent and delimiterDescent are how far a delimiter glyph
+	// reaches above and below its baseline, in em of its size
+	delimiterAscent  = 0.76
+	delimiterDescent = 0.13
+
+	// Delimiters from \left and \right cover at least delimiterFactor of
+	// the height and depth of what they enclose, and fall short of it by
+	// at most delimiterShortfall em, as with TeX's \delimiterfactor and
+	// \delimitershortfall
+	delimiterFactor    = 0.8
+	delimiterShortfall = 0.5
+
+	// nullDelimiterSpace is the width of the empty delimiter \left. or
+	// \right., in em
+	nullDelimiterSpace = 0.12
+)
+
+// bigDelimiterScale returns the scale of a delimiter set by a command such
+// as \big or \Bigl
+func bigDelimiterScale(name string) (float64, bool) {
+	if i, ok := bigDelimiters[name]; ok {
+		return delimiterScales[i], true
+	}
+	if i, ok := bigDelimiters[name[:max(len(name)-1, 0)]]; ok && strings.ContainsAny(name[len(name)-1:], "lrm") {
+		return delimiterScales[i], true
+	}
+	return 0, false
+}
+
+// bigDelimiterClass returns the class of a delimiter set by a command such
+// as \bigl: opening for the l forms, closing for r, relation for m and
+// ordinary for the plain commands
+func bigDelimiterClass(name string) atomClass {
+	switch name[len(name)-1] {
+	case 'l':
+		return openAtom
+	case 'r':
+		return closeAtom
+	case 'm':
+		return relAtom
+	}
+	return ordAtom
+}
+
+// delimiterSize returns the height of a delimiter drawn to cover a height,
+// centered on the math axis, with the scale of its glyph or 0 if it is
+// built from pieces
+func (m *MathProcessor) delimiterSize(symbol string, height float64, style mathStyle) (float64, float64) {
+	fontSize := m.size(style)
+	for _, scale := range delimiterScales {
+		if size := m.bigDelimiterSize(scale, style); size >= height {
+			return size, scale
+		}
+	}
+
+	pieces, ok := extensibleDelimiters[symbol]
+	if !ok {
+		return height, height / m.bigDelimiterSize(1, style)
+	}
+	// The top and bottom, and the middle of a brace, always show
+	count := 2.0
+	if pieces.middle != "" {
+		count = 3
+	}
+	return math.Max(height, count*(pieces.ascent+pieces.descent)*fontSize), 0
+}
+
+// bigDelimiterSize returns the height of a delimiter glyph at a scale, as
+// set by \big and the like whatever is around it
+func (m *MathProcessor) bigDelimiterSize(scale float64, style mathStyle) float64 {
+	return (delimiterAscent + delimiterDescent) * scale * m.size(style)
+}
+
+// renderDelimiter renders a delimiter that covers a height centered on the
+// math axis: a glyph of the smallest size that is tall enough, or one
+// built from pieces when even the largest is too short
+func (m *MathProcessor) renderDelimiter(symbol string, x, y, height float64, style mathStyle) float64 {
+	fontSize := m.size(style)
+	if symbol == "" {
+		return nullDelimiterSpace * fontSize
+	}
+
+	axis := y + mathAxis*fontSize
+	size, scale := m.delimiterSize(symbol, height, style)
+	if scale > 0 {
+		glyphSize := scale * fontSize
+		m.generator.AddText(symbol, x, axis-(delimiterAscent-delimiterDescent)/2*glyphSize, glyphSize, "normal")
+		return m.generator.GetTextWidth(symbol, glyphSize, "normal")
+	}
+
+	// The top and bottom pieces go at the ends, with extenders filling
+	// the space between them and the middle piece of a brace
+	pieces := extensibleDelimiters[symbol]
+	topY := axis + size/2 - pieces.ascent*fontSize
+	bottomY := axis - size/2 + pieces.descent*fontSize
+	m.generator.AddText(pieces.top, x, topY, fontSize, "normal")
+	m.generator.AddText(pieces.bottom, x, bottomY, fontSize, "normal")
+	if pieces.middle != "" {
+		middleY := axis - (pieces.ascent-pieces.descent)/2*fontSize
+		m.generator.AddText(pieces.middle, x, middleY, fontSize, "normal")
+		m.renderExtender(pieces, x, bottomY, middleY, fontSize)
+		m.renderExtender(pieces, x, middleY, topY, fontSize)
+	} else {
+		m.renderExtender(pieces, x, bottomY, topY, fontSize)
+	}
+	return m.piecesWidth(pieces, fontSize)
+}
+
+// renderExtender repeats the extender of a delimiter between the pieces
+// set on the baselines from and to
+func (m *MathProcessor) renderExtender(pieces delimiterPieces, x, from, to, fontSize float64) {
+	step := (pieces.ascent + pieces.descent) * fontSize
+	for y := from + step; y < to; y += step {
+		m.generator.AddText(pieces.extender, x, y, fontSize, "normal")
+	}
+}
+
+// piecesWidth calculates the width of a delimiter built from pieces
+func (m *MathProcessor) piecesWidth(pieces delimiterPieces, fontSize float64) float64 {
+	width := 0.0
+	for _, piece := range []string{pieces.top, pieces.extender, pieces.middle, pieces.bottom} {
+		if piece != "" {
+			width = math.Max(width, m.generator.GetTextWidth(piece, fontSize, "normal"))
+		}
+	}
+	return width
+}
+
+// delimiterWidth calculates the width of a delimiter that covers a height
+func (m *MathProcessor) delimiterWidth(symbol string, height float64, style mathStyle) float64 {
+	fontSize := m.size(style)
+	if symbol == "" {
+		return nullDelimiterSpace * fontSize
+	}
+	if _, scale := m.delimiterSize(symbol, height, style); scale > 0 {
+		return m.generator.GetTextWidth(symbol, scale*fontSize, "normal")
+	}
+	return m.piecesWidth(extensibleDelimiters[symbol], fontSize)
+}
+
+// delimiterCover returns the height delimiters around material of a
+// height and depth must cover: twice the distance from the math axis to
+// its top or bottom, whichever is further
+func (m *MathProcessor) delimiterCover(height, depth float64, style mathStyle) float64 {
+	axis := mathAxis * m.size(style)
+	total := 2 * math.Max(height-axis, depth+axis)
+	return math.Max(total*delimiterFactor, total-delimiterShortfall*m.size(style))
+}
+
+// delimitersExtent returns how far material of a height and depth reaches
+// above and below its baseline with delimiters around it that cover a
+// height
+func (m *MathProcessor) delimitersExtent(height, depth, cover float64, style mathStyle, symbols ...string) (float64, float64) {
+	axis := mathAxis * m.size(style)
+	for _, symbol := range symbols {
+		if symbol != "" {
+			size, _ := m.delimiterSize(symbol, cover, style)
+			height, depth = math.Max(height, axis+size/2), math.Max(depth, size/2-axis)
+		}
+	}
+	return height, depth
+}
+
+// delimitedHeight returns the height the delimiters of \left and \right
+// must cover
+func (m *MathProcessor) delimitedHeight(delimited *parser.MathDelimited, style mathStyle) float64 {
+	height, depth := m.listExtent(delimited.Content, style)
+	return m.delimiterCover(height, depth, style)
+}
+
+// splitMiddle splits the content of \left and \right at each \middle,
+// returning the parts between them and the \middle delimiters
+func splitMiddle(nodes []parser.Node) (parts [][]parser.Node, middles []string) {
+	start := 0
+	for i, node := range nodes {
+		if cmd, ok := node.(*parser.Command); ok && cmd.Name == "middle" {
+			parts = append(parts, nodes[start:i])
+			middles = append(middles, delimiterSymbol(cmd))
+			start = i + 1
+		}
+	}
+	return append(parts, nodes[start:]), middles
+}
+
+// delimiterSymbol returns the delimiter of \middle or \big
+func delimiterSymbol(cmd *parser.Command) string {
+	if len(cmd.Args) > 0 {
+		if symbol, ok := cmd.Args[0].(*parser.MathSymbol); ok {
+			return symbol.Symbol
+		}
+	}
+	return ""
+}
+
+// renderDelimited renders math between \left and \right, with delimiters
+// as tall as what they enclose. Each \middle is as tall as they are.
+func (m *MathProcessor) renderDelimited(delimited *parser.MathDelimited, x, y float64, style mathStyle) float64 {
+	height := m.delimitedHeight(delimited, style)
+	parts, middles := splitMiddle(delimited.Content)
+
+	currentX := x + m.renderDelimiter(delimited.Left, x, y, height, style)
+	for i, part := range parts {
+		if i > 0 {
+			currentX += m.renderDelimiter(middles[i-1], currentX, y, height, style)
+		}
+		currentX += m.renderList(part, currentX, y, style)
+	}
+	currentX += m.renderDelimiter(delimited.Right, currentX, y, height, style)
+	return currentX - x
+}
+
+// delimitedWidth calculates the width of math between \left and \right
+func (m *MathProcessor) delimitedWidth(delimited *parser.MathDelimited, style mathStyle) float64 {
+	height := m.delimitedHeight(delimited, style)
+	parts, middles := splitMiddle(delimited.Content)
+
+	width := m.delimiterWidth(delimited.Left, height, style) + m.delimiterWidth(delimited.Right, height, style)
+	for _, part := range parts {
+		width += m.listWidth(part, style)
+	}
+	for _, middle := range middles {
+		width += m.delimiterWidth(middle, height, style)
+	}
+	return width
+}
+
+// delimitedExtent returns how far math between \left and \right reaches
+// above and below its baseline, taking in its delimiters
+func (m *MathProcessor) delimitedExtent(delimited *parser.MathDelimited, style mathStyle) (float64, float64) {
+	height, depth := m.listExtent(delimited.Content, style)
+	cover := m.delimiterCover(height, depth, style)
+	return m.delimitersExtent(height, depth, cover, style, delimited.Left, delimited.Right)
+}
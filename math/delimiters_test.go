@@ -0,0 +1,40 @@
+package math
+
+import "testing"
+
+func TestDelimiterSize(t *testing.T) {
+	m := &MathProcessor{fontSize: 10}
+	glyph := m.bigDelimiterSize(1, textStyle)
+
+	tests := []struct {
+		name   string
+		symbol string
+		height float64
+		scale  float64
+	}{
+		{"text height", "(", glyph, 1},
+		{"a little taller", "(", glyph * 1.1, 1.2},
+		{"Bigg", "[", m.bigDelimiterSize(3, textStyle), 3},
+		{"taller than Bigg", "(", glyph * 4, 0},
+		{"angle brackets keep scaling", "⟨", glyph * 4, 4},
+	}
+
+	for _, tt := range tests {
+		size, scale := m.delimiterSize(tt.symbol, tt.height, textStyle)
+		if scale != tt.scale {
+			t.Errorf("%s: expected scale %.1f, got %.1f", tt.name, tt.scale, scale)
+		}
+		if size < tt.height {
+			t.Errorf("%s: delimiter of %.1f does not cover %.1f", tt.name, size, tt.height)
+		}
+	}
+
+	for name, expected := range map[string]float64{"big": 1.2, "Bigl": 1.8, "biggr": 2.4, "Biggm": 3} {
+		if scale, ok := bigDelimiterScale(name); !ok || scale != expected {
+			t.Errorf("\\%s: expected scale %.1f, got %.1f", name, expected, scale)
+		}
+	}
+	if _, ok := bigDelimiterScale("bigx"); ok {
+		t.Errorf("\\bigx is not a delimiter command")
+	}
+}
@@ -0,0 +1,64 @@
+package math
+
+import "github.com/rickykimani/gotex/parser"
+
+// multlineGap is how far the first line of multline is set from the left
+// margin and the last from the right, in em
+const multlineGap = 1.0
+
+// DisplayRows are the measured lines of an align, gather or multline
+// environment. The document sets them one at a time, with the equation
+// numbers and any text between them.
+type DisplayRows struct {
+	grid     arrayGrid
+	multline bool
+}
+
+// MeasureDisplayRows measures the lines of an align, gather or multline
+// environment, each row of the array being a line
+func (m *MathProcessor) MeasureDisplayRows(array *parser.MathArray) *DisplayRows {
+	return &DisplayRows{
+		grid:     m.measureArray(array, displayStyle),
+		multline: array.Name == "multline",
+	}
+}
+
+// Len returns the number of lines
+func (d *DisplayRows) Len() int {
+	return len(d.grid.rows)
+}
+
+// Extent returns how far line i reaches above and below its baseline
+func (d *DisplayRows) Extent(i int) (height, depth float64) {
+	return d.grid.heights[i], d.grid.depths[i]
+}
+
+// RenderDisplayRow renders line i of display rows on a baseline. The
+// columns of align and gather are centered between the margins as a
+// whole. The first line of multline is set at the left, the last at the
+// right clear of an equation number reserve wide, and those between
+// centered.
+func (m *MathProcessor) RenderDisplayRow(d *DisplayRows, i int, y, reserve float64) {
+	g := d.grid
+	left := m.generator.MarginLeft
+	contentWidth := m.generator.GetContentWidth()
+	x := left + (contentWidth-g.width)/2
+
+	if g.layout.columns == "c" {
+		// Each line of gather and multline is set on its own
+		width := g.cellWidths[i][0]
+		gap := multlineGap * m.size(displayStyle)
+		last := len(g.rows) - 1
+		switch {
+		case d.multline && last > 0 && i == 0:
+			x = left + gap
+		case d.multline && last > 0 && i == last:
+			x = left + contentWidth - width - gap - reserve
+		default:
+			x = left + (contentWidth-width)/2
+		}
+		m.renderMathElement(g.rows[i][0], x, y, g.style)
+		return
+	}
+	m.renderRow(g, i, x, y)
+}
@@ -0,0 +1,115 @@
+package math
+
+import (
+	"math"
+
+	"github.com/rickykimani/gotex/parser"
+)
+
+// textHeight and textDepth are how far a line of math text reaches above
+// and below its baseline, in em: about as far as a parenthesis does
+const (
+	textHeight = 0.76
+	textDepth  = 0.13
+)
+
+// listExtent returns how far a math list reaches above and below its
+// baseline
+func (m *MathProcessor) listExtent(nodes []parser.Node, style mathStyle) (height, depth float64) {
+	for _, a := range m.atoms(nodes, style) {
+		if a.kern {
+			continue
+		}
+		h, d := m.elementExtent(a.node, a.style)
+		height, depth = math.Max(height, h), math.Max(depth, d)
+	}
+	return height, depth
+}
+
+// elementExtent returns how far a math element reaches above and below
+// the baseline it is set on
+func (m *MathProcessor) elementExtent(node parser.Node, style mathStyle) (height, depth float64) {
+	fontSize := m.size(style)
+	switch n := node.(type) {
+	case nil:
+		return 0, 0
+
+	case *parser.TextNode:
+		return textHeight * fontSize, textDepth * fontSize
+
+	case *parser.MathSymbol:
+		if _, ok := largeOperator(n); ok {
+			return m.operatorExtent(n, style)
+		}
+		return textHeight * fontSize, textDepth * fontSize
+
+	case *parser.Group:
+		return m.listExtent(n.Nodes, style)
+
+	case *parser.MathFraction:
+		numShift, denShift := m.fractionShifts(n, style)
+		numHeight, _ := m.elementExtent(n.Numerator, style.numerator())
+		_, denDepth := m.elementExtent(n.Denominator, style.denominator())
+		return numShift + numHeight, denShift + denDepth
+
+	case *parser.MathSuperscript, *parser.MathSubscript:
+		l := m.layoutScripts(n, style)
+		height, depth = m.elementExtent(l.base, style)
+		if l.sup != nil {
+			supHeight, supDepth := m.elementExtent(l.sup, l.supStyle)
+			height = math.Max(height, l.supY+supHeight)
+			depth = math.Max(depth, supDepth-l.supY)
+		}
+		if l.sub != nil {
+			subHeight, subDepth := m.elementExtent(l.sub, l.subStyle)
+			height = math.Max(height, l.subY+subHeight)
+			depth = math.Max(depth, subDepth-l.subY)
+		}
+		return height, depth
+
+	case *parser.MathDelimited:
+		return m.delimitedExtent(n, style)
+
+	case *parser.MathArray:
+		return m.arrayExtent(n, style)
+
+	case *parser.MathAccent:
+		return m.accentExtent(n, style)
+
+	case *parser.MathOverUnder:
+		return m.overUnderExtent(n, style)
+
+	case *parser.Command:
+		if _, ok := m.lookupOperator(n); ok {
+			return textHeight * fontSize, textDepth * fontSize
+		}
+		if frac, fracStyle, ok := commandFraction(n, style); ok {
+			return m.elementExtent(frac, fracStyle)
+		}
+		if scale, ok := bigDelimiterScale(n.Name); ok {
+			size := m.bigDelimiterSize(scale, style)
+			axis := mathAxis * fontSize
+			return axis + size/2, size/2 - axis
+		}
+		switch {
+		case n.Name == "sqrt" && len(n.Args) > 0:
+			// The vinculum is drawn over the radicand
+			height, depth = m.elementExtent(n.Args[0], style.cramp())
+			return math.Max(height, 0.8*fontSize+fractionRule*fontSize), depth
+		case n.Name == "middle":
+			// \middle grows with the delimiters around it
+			return 0, 0
+		case limitsCommands[n.Name]:
+			if len(n.Args) > 0 {
+				return m.elementExtent(n.Args[0], style)
+			}
+			return 0, 0
+		}
+		for _, arg := range n.Args {
+			h, d := m.elementExtent(arg, style)
+			height, depth = math.Max(height, h), math.Max(depth, d)
+		}
+		return height, depth
+	}
+	return textHeight * fontSize, textDepth * fontSize
+}
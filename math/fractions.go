@@ -7,22 +7,48 @@ import (
 	"github.com/rickykimani/gotex/parser"
 )
 
-// renderFraction renders fractions using improved positioning based on go-latex approach
-func (m *MathProcessor) renderFraction(frac *parser.MathFraction, x, y, fontSize float64) float64 {
-	// Use smaller font for numerator and denominator (similar to go-latex's shrink factor)
-	fracFontSize := fontSize * 0.7
+// fractionRule is the thickness of a fraction bar in em
+const fractionRule = 0.05
+
+// fractionStyles are the styles \dfrac and \tfrac set their fractions in
+var fractionStyles = map[string]mathStyle{
+	"dfrac": displayStyle,
+	"tfrac": textStyle,
+}
+
+// commandFraction returns the fraction a \frac, \dfrac or \tfrac command
+// sets and the style it is set in
+func commandFraction(cmd *parser.Command, style mathStyle) (*parser.MathFraction, mathStyle, bool) {
+	if len(cmd.Args) < 2 {
+		return nil, style, false
+	}
+	if target, ok := fractionStyles[cmd.Name]; ok {
+		style = style.switchTo(target)
+	} else if cmd.Name != "frac" {
+		return nil, style, false
+	}
+	return &parser.MathFraction{
+		Numerator:   cmd.Args[0],
+		Denominator: cmd.Args[1],
+		Position:    cmd.Position,
+	}, style, true
+}
+
+// renderFraction renders fractions using improved positioning based on go-latex approach.
+// The numerator and denominator are set a style smaller than the fraction,
+// so a fraction in display style has full size parts and one in text style
+// script size parts.
+func (m *MathProcessor) renderFraction(frac *parser.MathFraction, x, y float64, style mathStyle) float64 {
+	fontSize := m.size(style)
+	numStyle, denStyle := style.numerator(), style.denominator()
 
 	// Calculate widths for proper centering
-	numWidth := m.calculateElementWidth(frac.Numerator, fracFontSize)
-	denWidth := m.calculateElementWidth(frac.Denominator, fracFontSize)
+	numWidth := m.calculateElementWidth(frac.Numerator, numStyle)
+	denWidth := m.calculateElementWidth(frac.Denominator, denStyle)
 	maxWidth := math.Max(numWidth, denWidth)
 
-	// Calculate heights to determine proper spacing for nested fractions
-	numHeight := m.calculateElementHeight(frac.Numerator, fracFontSize)
-	denHeight := m.calculateElementHeight(frac.Denominator, fracFontSize)
-
 	// Add padding similar to go-latex (2 * thickness)
-	thickness := fontSize * 0.05 // Approximate line thickness
+	thickness := fontSize * fractionRule
 	padding := 2 * thickness
 	totalWidth := maxWidth + 2*padding
 
@@ -32,6 +58,29 @@ func (m *MathProcessor) renderFraction(frac *parser.MathFraction, x, y, fontSize
 
 	// Position fraction line at baseline (like equals sign middle)
 	lineY := y
+	numShift, denShift := m.fractionShifts(frac, style)
+	numY := lineY + numShift
+	denY := lineY - denShift
+
+	// Render components
+	m.renderMathElement(frac.Numerator, numX, numY, numStyle)   // Numerator
+	m.renderMathElement(frac.Denominator, denX, denY, denStyle) // Denominator
+
+	// Draw fraction line with proper thickness - ensure it covers the full width
+	m.generator.AddLine(x+padding, lineY, x+padding+maxWidth, lineY)
+
+	return totalWidth
+}
+
+// fractionShifts returns how far the baselines of a fraction's numerator
+// and denominator are above and below its bar
+func (m *MathProcessor) fractionShifts(frac *parser.MathFraction, style mathStyle) (numShift, denShift float64) {
+	fontSize := m.size(style)
+	numStyle, denStyle := style.numerator(), style.denominator()
+
+	// Calculate heights to determine proper spacing for nested fractions
+	numHeight := m.calculateElementHeight(frac.Numerator, numStyle)
+	denHeight := m.calculateElementHeight(frac.Denominator, denStyle)
 
 	// Calculate vertical spacing based on actual element heights
 	// Use larger gaps for nested fractions to prevent overlap
@@ -46,24 +95,14 @@ func (m *MathProcessor) renderFraction(frac *parser.MathFraction, x, y, fontSize
 		numGap += fontSize * 0.15 // More additional gap for fraction numerators
 	}
 
-	// Position numerator above the line (higher Y value in this coordinate system)
-	numY := lineY + numGap + (fracFontSize * 0.2)
-
-	// Position denominator below the line (lower Y value in this coordinate system)
-	denY := lineY - denGap - (fracFontSize * 0.8)
-
-	// Render components
-	m.renderMathElement(frac.Numerator, numX, numY, fracFontSize)   // Numerator
-	m.renderMathElement(frac.Denominator, denX, denY, fracFontSize) // Denominator
-
-	// Draw fraction line with proper thickness - ensure it covers the full width
-	m.generator.AddLine(x+padding, lineY, x+padding+maxWidth, lineY)
-
-	return totalWidth
+	// Numerator above the line (higher Y value in this coordinate system),
+	// denominator below it
+	return numGap + m.size(numStyle)*0.2, denGap + m.size(denStyle)*0.8
 }
 
 // calculateElementHeight calculates the approximate height of a math element
-func (m *MathProcessor) calculateElementHeight(node parser.Node, fontSize float64) float64 {
+func (m *MathProcessor) calculateElementHeight(node parser.Node, style mathStyle) float64 {
+	fontSize := m.size(style)
 	if node == nil {
 		return fontSize
 	}
@@ -74,24 +113,24 @@ func (m *MathProcessor) calculateElementHeight(node parser.Node, fontSize float6
 
 	case *parser.MathFraction:
 		// For nested fractions, calculate total height with proper spacing
-		numHeight := m.calculateElementHeight(n.Numerator, fontSize*0.7)
-		denHeight := m.calculateElementHeight(n.Denominator, fontSize*0.7)
+		numHeight := m.calculateElementHeight(n.Numerator, style.numerator())
+		denHeight := m.calculateElementHeight(n.Denominator, style.denominator())
 		// Add extra spacing for nested fractions to prevent overlap
 		return numHeight + denHeight + fontSize*0.6
 
 	case *parser.MathSuperscript:
-		baseHeight := m.calculateElementHeight(n.Base, fontSize)
-		expHeight := m.calculateElementHeight(n.Exponent, fontSize*0.7)
+		baseHeight := m.calculateElementHeight(n.Base, style)
+		expHeight := m.calculateElementHeight(n.Exponent, style.superscript())
 		return baseHeight + expHeight*0.5 // Superscript adds to height
 
 	case *parser.MathSubscript:
-		baseHeight := m.calculateElementHeight(n.Base, fontSize)
+		baseHeight := m.calculateElementHeight(n.Base, style)
 		return baseHeight + fontSize*0.3 // Subscript adds some height
 
 	case *parser.Group:
 		maxHeight := fontSize
 		for _, child := range n.Nodes {
-			h := m.calculateElementHeight(child, fontSize)
+			h := m.calculateElementHeight(child, style)
 			if h > maxHeight {
 				maxHeight = h
 			}
@@ -99,10 +138,13 @@ func (m *MathProcessor) calculateElementHeight(node parser.Node, fontSize float6
 		return maxHeight
 
 	case *parser.Command:
+		if frac, fracStyle, ok := commandFraction(n, style); ok {
+			return m.calculateElementHeight(frac, fracStyle)
+		}
 		// For commands with arguments, check their heights
 		maxHeight := fontSize
 		for _, arg := range n.Args {
-			h := m.calculateElementHeight(arg, fontSize)
+			h := m.calculateElementHeight(arg, style)
 			if h > maxHeight {
 				maxHeight = h
 			}
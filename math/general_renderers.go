@@ -8,7 +8,7 @@ import (
 )
 
 // renderMathElement renders a single math element
-func (m *MathProcessor) renderMathElement(node parser.Node, x, y, fontSize float64) float64 {
+func (m *MathProcessor) renderMathElement(node parser.Node, x, y float64, style mathStyle) float64 {
 	// Safety check for nil nodes
 	if node == nil {
 		return 0
@@ -16,31 +16,45 @@ func (m *MathProcessor) renderMathElement(node parser.Node, x, y, fontSize float
 
 	switch n := node.(type) {
 	case *parser.TextNode:
-		return m.renderMathText(n.Value, x, y, fontSize)
+		return m.renderList([]parser.Node{n}, x, y, style)
 
 	case *parser.Command:
-		return m.renderMathCommand(n, x, y, fontSize)
+		return m.renderMathCommand(n, x, y, style)
 
 	case *parser.MathSymbol:
-		return m.renderMathSymbol(n, x, y, fontSize)
+		if symbol, ok := largeOperator(n); ok {
+			return m.renderLargeOperator(symbol, x, y, style)
+		}
+		return m.renderMathSymbol(n, x, y, m.size(style))
 
 	case *parser.MathSuperscript:
-		return m.renderSuperscript(n, x, y, fontSize)
+		return m.renderSuperscript(n, x, y, style)
 
 	case *parser.MathSubscript:
-		return m.renderSubscript(n, x, y, fontSize)
+		return m.renderSubscript(n, x, y, style)
 
 	case *parser.MathFraction:
-		return m.renderFraction(n, x, y, fontSize)
+		return m.renderFraction(n, x, y, style)
 
 	case *parser.Group:
-		return m.renderGroup(n, x, y, fontSize)
+		return m.renderGroup(n, x, y, style)
+
+	case *parser.MathDelimited:
+		return m.renderDelimited(n, x, y, style)
+
+	case *parser.MathArray:
+		return m.renderArray(n, x, y, style)
+
+	case *parser.MathAccent:
+		return m.renderAccent(n, x, y, style)
+
+	case *parser.MathOverUnder:
+		return m.renderOverUnder(n, x, y, style)
+
+	case *parser.MathText:
+		return m.renderText(n, x, y, style)
 
 	default:
-		// Fallback: treat as text
-		if textNode, ok := node.(*parser.TextNode); ok {
-			return m.renderMathText(textNode.Value, x, y, fontSize)
-		}
 		return 0
 	}
 }
@@ -53,47 +67,63 @@ func (m *MathProcessor) renderMathText(text string, x, y, fontSize float64) floa
 		return 0
 	}
 
-	// Choose appropriate font
-	fontStyle := GetMathFont(text)
-
-	// Render the text
-	m.generator.AddText(text, x, y, fontSize, fontStyle)
-
-	// Return width
-	return m.generator.GetTextWidth(text, fontSize, fontStyle)
+	// Render each run in its font
+	currentX := x
+	for _, run := range m.textRuns(text) {
+		m.generator.AddText(run.text, currentX, y, fontSize, run.style)
+		currentX += m.generator.GetTextWidth(run.text, fontSize, run.style)
+	}
+	return currentX - x
 }
 
 // renderMathCommand renders LaTeX math commands
-func (m *MathProcessor) renderMathCommand(cmd *parser.Command, x, y, fontSize float64) float64 {
+func (m *MathProcessor) renderMathCommand(cmd *parser.Command, x, y float64, style mathStyle) float64 {
+	fontSize := m.size(style)
+
+	if op, ok := m.lookupOperator(cmd); ok {
+		return m.renderOperator(op, x, y, style)
+	}
+
 	// Check if it's a known math symbol
 	if symbol, exists := symbols.ConvertMathSymbol(cmd.Name); exists {
 		m.generator.AddText(symbol, x, y, fontSize, "normal")
 		return m.generator.GetTextWidth(symbol, fontSize, "normal")
 	}
 
+	if em, ok := explicitSpaces[cmd.Name]; ok {
+		return em * fontSize
+	}
+
+	if _, ok := mathAlphabets[cmd.Name]; ok && len(cmd.Args) > 0 {
+		return m.inAlphabet(cmd.Name, func() float64 {
+			return m.renderMathElement(cmd.Args[0], x, y, style)
+		})
+	}
+
+	if frac, fracStyle, ok := commandFraction(cmd, style); ok {
+		return m.renderFraction(frac, x, y, fracStyle)
+	}
+
+	if scale, ok := bigDelimiterScale(cmd.Name); ok {
+		return m.renderDelimiter(delimiterSymbol(cmd), x, y, m.bigDelimiterSize(scale, style), style)
+	}
+
 	// Handle special commands
 	switch cmd.Name {
-	case "frac":
-		if len(cmd.Args) >= 2 {
-			// Create a temporary fraction node
-			fracNode := &parser.MathFraction{
-				Numerator:   cmd.Args[0],
-				Denominator: cmd.Args[1],
-				Position:    cmd.Position,
-			}
-			return m.renderFraction(fracNode, x, y, fontSize)
-		}
+	case "middle":
+		// Outside \left and \right there is nothing to grow with
+		return m.renderDelimiter(delimiterSymbol(cmd), x, y, 0, style)
 
 	case "sqrt":
 		if len(cmd.Args) >= 1 {
-			return m.renderSquareRoot(cmd.Args[0], x, y, fontSize)
+			return m.renderSquareRoot(cmd.Args[0], x, y, style)
 		}
 
 	default:
 		// For unknown commands, just render the arguments
 		currentX := x
 		for _, arg := range cmd.Args {
-			width := m.renderMathElement(arg, currentX, y, fontSize)
+			width := m.renderMathElement(arg, currentX, y, style)
 			currentX += width
 		}
 		return currentX - x
@@ -109,24 +139,24 @@ func (m *MathProcessor) renderMathSymbol(symbol *parser.MathSymbol, x, y, fontSi
 		return 0
 	}
 
-	// Use normal font for math symbols to ensure compatibility
-	m.generator.AddText(symbol.Symbol, x, y, fontSize, "normal")
-	return m.generator.GetTextWidth(symbol.Symbol, fontSize, "normal")
+	return m.renderMathText(symbol.Symbol, x, y, fontSize)
 }
 
 // renderGroup renders a group of math elements (like braced content)
-func (m *MathProcessor) renderGroup(group *parser.Group, x, y, fontSize float64) float64 {
-	currentX := x
+func (m *MathProcessor) renderGroup(group *parser.Group, x, y float64, style mathStyle) float64 {
+	return m.renderList(group.Nodes, x, y, style)
+}
 
-	for i, node := range group.Nodes {
-		// Add spacing between elements if needed
-		if i > 0 {
-			currentX += m.getSpacing(group.Nodes[i-1], node)
+// renderList renders a math list, with the space between its atoms
+func (m *MathProcessor) renderList(nodes []parser.Node, x, y float64, style mathStyle) float64 {
+	currentX := x
+	for _, a := range m.atoms(nodes, style) {
+		currentX += a.space
+		if text, ok := a.node.(*parser.TextNode); ok {
+			currentX += m.renderMathText(text.Value, currentX, y, m.size(a.style))
+		} else {
+			currentX += m.renderMathElement(a.node, currentX, y, a.style)
 		}
-
-		width := m.renderMathElement(node, currentX, y, fontSize)
-		currentX += width
 	}
-
 	return currentX - x // Return total width
 }
@@ -7,72 +7,107 @@ import (
 	"github.com/rickykimani/gotex/symbols"
 )
 
-// calculateMathWidth calculates the total width of a math expression
+// CalculateMathWidth calculates the total width of a math expression set
+// in text style, as inline math is
 func (m *MathProcessor) CalculateMathWidth(content []parser.Node) float64 {
-	totalWidth := 0.0
+	return m.listWidth(content, textStyle)
+}
 
-	for i, node := range content {
-		if i > 0 {
-			totalWidth += m.getSpacing(content[i-1], node)
+// listWidth calculates the width of a math list, with the space between
+// its atoms
+func (m *MathProcessor) listWidth(nodes []parser.Node, style mathStyle) float64 {
+	width := 0.0
+	for _, a := range m.atoms(nodes, style) {
+		width += a.space
+		if text, ok := a.node.(*parser.TextNode); ok {
+			width += m.mathTextWidth(text.Value, m.size(a.style))
+		} else {
+			width += m.calculateElementWidth(a.node, a.style)
 		}
-		totalWidth += m.calculateElementWidth(node, m.fontSize)
 	}
-
-	return totalWidth
+	return width
 }
 
 // calculateElementWidth calculates the width of a single math element
-func (m *MathProcessor) calculateElementWidth(node parser.Node, fontSize float64) float64 {
+func (m *MathProcessor) calculateElementWidth(node parser.Node, style mathStyle) float64 {
 	// Safety check for nil nodes
 	if node == nil {
 		return 0
 	}
 
+	fontSize := m.size(style)
 	switch n := node.(type) {
 	case *parser.TextNode:
-		fontStyle := GetMathFont(n.Value)
-		return m.generator.GetTextWidth(n.Value, fontSize, fontStyle)
+		return m.listWidth([]parser.Node{n}, style)
 
 	case *parser.Command:
+		if op, ok := m.lookupOperator(n); ok {
+			return m.operatorWidth(op, style)
+		}
 		if symbol, exists := symbols.ConvertMathSymbol(n.Name); exists {
 			return m.generator.GetTextWidth(symbol, fontSize, "normal")
 		}
+		if em, ok := explicitSpaces[n.Name]; ok {
+			return em * fontSize
+		}
+		if _, ok := mathAlphabets[n.Name]; ok && len(n.Args) > 0 {
+			return m.inAlphabet(n.Name, func() float64 {
+				return m.calculateElementWidth(n.Args[0], style)
+			})
+		}
+		if frac, fracStyle, ok := commandFraction(n, style); ok {
+			return m.calculateElementWidth(frac, fracStyle)
+		}
+		if scale, ok := bigDelimiterScale(n.Name); ok {
+			return m.delimiterWidth(delimiterSymbol(n), m.bigDelimiterSize(scale, style), style)
+		}
+		if n.Name == "middle" {
+			return m.delimiterWidth(delimiterSymbol(n), 0, style)
+		}
+		if n.Name == "sqrt" && len(n.Args) > 0 {
+			return m.generator.GetTextWidth("√", fontSize, "normal") + m.calculateElementWidth(n.Args[0], style.cramp())
+		}
 		// For other commands, approximate based on arguments
 		totalWidth := 0.0
 		for _, arg := range n.Args {
-			totalWidth += m.calculateElementWidth(arg, fontSize)
+			totalWidth += m.calculateElementWidth(arg, style)
 		}
 		return totalWidth
 
 	case *parser.Group:
-		totalWidth := 0.0
-		for i, node := range n.Nodes {
-			// Add spacing between elements if needed
-			if i > 0 {
-				totalWidth += m.getSpacing(n.Nodes[i-1], node)
-			}
-			totalWidth += m.calculateElementWidth(node, fontSize)
-		}
-		return totalWidth
+		return m.listWidth(n.Nodes, style)
 
-	case *parser.MathFraction:
-		// For fractions, return the width of the wider element
-		numWidth := m.calculateElementWidth(n.Numerator, fontSize*0.7)
-		denWidth := m.calculateElementWidth(n.Denominator, fontSize*0.7)
-		return math.Max(numWidth, denWidth)
+	case *parser.MathDelimited:
+		return m.delimitedWidth(n, style)
+
+	case *parser.MathArray:
+		return m.arrayWidth(n, style)
+
+	case *parser.MathAccent:
+		// An accent takes the width of its base
+		return m.calculateElementWidth(n.Base, style.cramp())
 
-	case *parser.MathSuperscript:
-		baseWidth := m.calculateElementWidth(n.Base, fontSize)
-		expWidth := m.calculateElementWidth(n.Exponent, fontSize*0.7)
-		return baseWidth + expWidth*0.8 // Superscript adds to width
+	case *parser.MathOverUnder:
+		return m.overUnderWidth(n, style)
 
-	case *parser.MathSubscript:
-		baseWidth := m.calculateElementWidth(n.Base, fontSize)
-		subWidth := m.calculateElementWidth(n.Index, fontSize*0.7)
-		return baseWidth + subWidth*0.8 // Subscript adds to width
+	case *parser.MathText:
+		return m.textWidth(n, style)
+
+	case *parser.MathFraction:
+		// The wider of the numerator and denominator, with the padding
+		// on either side of the bar
+		numWidth := m.calculateElementWidth(n.Numerator, style.numerator())
+		denWidth := m.calculateElementWidth(n.Denominator, style.denominator())
+		return math.Max(numWidth, denWidth) + 4*fractionRule*fontSize
+
+	case *parser.MathSuperscript, *parser.MathSubscript:
+		return m.layoutScripts(n, style).width
 
 	case *parser.MathSymbol:
-		return m.generator.GetTextWidth(n.Symbol, fontSize, "normal")
+		if symbol, ok := largeOperator(n); ok {
+			return m.largeOperatorWidth(symbol, style)
+		}
+		return m.mathTextWidth(n.Symbol, fontSize)
 
 	default:
 		// Default approximation
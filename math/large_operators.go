@@ -0,0 +1,93 @@
+package math
+
+import (
+	"slices"
+
+	"github.com/rickykimani/gotex/parser"
+)
+
+var (
+	// largeOperators are the symbols of large operators such as \sum and
+	// \bigcup, which are set bigger in display style
+	largeOperators = []string{"∑", "∏", "∐", "∫", "∮", "∬", "∭", "⋃", "⋂", "⋁", "⋀", "⨁", "⨂", "⨀"}
+
+	// integrals are the large operators whose scripts stay beside them in
+	// display style, unless \limits moves them
+	integrals = []string{"∫", "∮", "∬", "∭"}
+)
+
+const (
+	// displayOperatorScale and displayIntegralScale are how much bigger
+	// large operators and integrals are in display style
+	displayOperatorScale = 1.4
+	displayIntegralScale = 2.0
+
+	// mathAxis is the height of the math axis, which fraction bars and the
+	// middle of large operators sit on, in em
+	mathAxis = 0.313
+
+	// operatorAscent and operatorDescent are how far the glyph of a large
+	// operator reaches above and below its baseline, in em of its size
+	operatorAscent  = 0.72
+	operatorDescent = 0.2
+
+	// integralKern is how far the subscript of an integral tucks in under
+	// its slant, in em of its size
+	integralKern = 0.15
+)
+
+// isLargeOperator reports whether a symbol is a large operator
+func isLargeOperator(symbol string) bool {
+	return slices.Contains(largeOperators, symbol)
+}
+
+// largeOperator returns the symbol of a node that is a large operator
+func largeOperator(node parser.Node) (string, bool) {
+	if symbol, ok := node.(*parser.MathSymbol); ok && isLargeOperator(symbol.Symbol) {
+		return symbol.Symbol, true
+	}
+	return "", false
+}
+
+// operatorSize returns the font size of a large operator's glyph in a style
+func (m *MathProcessor) operatorSize(symbol string, style mathStyle) float64 {
+	size := m.size(style)
+	if style.isDisplay() {
+		if slices.Contains(integrals, symbol) {
+			return size * displayIntegralScale
+		}
+		return size * displayOperatorScale
+	}
+	return size
+}
+
+// operatorShift is how far a large operator's glyph is raised to center it
+// on the math axis
+func (m *MathProcessor) operatorShift(symbol string, style mathStyle) float64 {
+	glyphSize := m.operatorSize(symbol, style)
+	return mathAxis*m.size(style) - (operatorAscent-operatorDescent)/2*glyphSize
+}
+
+// renderLargeOperator renders a large operator centered on the math axis
+func (m *MathProcessor) renderLargeOperator(symbol string, x, y float64, style mathStyle) float64 {
+	glyphSize := m.operatorSize(symbol, style)
+	m.generator.AddText(symbol, x, y+m.operatorShift(symbol, style), glyphSize, "normal")
+	return m.generator.GetTextWidth(symbol, glyphSize, "normal")
+}
+
+// largeOperatorWidth calculates the width of a large operator
+func (m *MathProcessor) largeOperatorWidth(symbol string, style mathStyle) float64 {
+	return m.generator.GetTextWidth(symbol, m.operatorSize(symbol, style), "normal")
+}
+
+// operatorExtent returns how far an operator reaches above and below the
+// baseline, which its limits and scripts are placed clear of
+func (m *MathProcessor) operatorExtent(node parser.Node, style mathStyle) (height, depth float64) {
+	if symbol, ok := largeOperator(node); ok {
+		glyphSize := m.operatorSize(symbol, style)
+		shift := m.operatorShift(symbol, style)
+		return operatorAscent*glyphSize + shift, operatorDescent*glyphSize - shift
+	}
+	// Operator names are set as letters without descenders
+	return operatorAscent * m.size(style), 0
+}
@@ -1,3 +1,6 @@
 package math
 
-//TODO: implement \log_{}() and \ln
+// logarithmicFunctions are the operator names of logarithms and the
+// exponential function. A base is written as a subscript, as in \log_2 x,
+// and stays beside the name.
+var logarithmicFunctions = []string{"log", "ln", "lg", "exp"}
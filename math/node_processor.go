@@ -2,6 +2,15 @@ package math
 
 import "github.com/rickykimani/gotex/parser"
 
+// MathExtent returns how far a math node reaches above and below its
+// baseline
+func (m *MathProcessor) MathExtent(node *parser.MathNode) (height, depth float64) {
+	if node.Inline {
+		return m.listExtent(node.Content, textStyle)
+	}
+	return m.listExtent(node.Content, displayStyle)
+}
+
 // ProcessMathNode renders a math node to PDF
 func (m *MathProcessor) ProcessMathNode(node *parser.MathNode, x, y float64) float64 {
 	if node.Inline {
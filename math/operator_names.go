@@ -0,0 +1,110 @@
+package math
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/rickykimani/gotex/parser"
+)
+
+// limitOperators are the operator names whose limits go under them in
+// display style, with the text they are set as
+var limitOperators = map[string]string{
+	"lim":     "lim",
+	"liminf":  "lim inf",
+	"limsup":  "lim sup",
+	"injlim":  "inj lim",
+	"projlim": "proj lim",
+	"max":     "max",
+	"min":     "min",
+	"sup":     "sup",
+	"inf":     "inf",
+	"det":     "det",
+	"gcd":     "gcd",
+	"Pr":      "Pr",
+}
+
+// functionNames are the other operator names that are not trigonometric
+// or logarithmic
+var functionNames = []string{"arg", "deg", "dim", "hom", "ker"}
+
+// operator is an operator name such as \sin, \operatorname{tr} or one
+// added by \DeclareMathOperator. Built-in names are set as their words with
+// thin spaces between them, and the others as their math.
+type operator struct {
+	words  []string
+	body   []parser.Node
+	limits bool
+}
+
+// DeclareOperator defines \name as an operator name set as body, as
+// \DeclareMathOperator does. Limits go under it in display style if limits
+// is set, as with \DeclareMathOperator*.
+func (m *MathProcessor) DeclareOperator(name string, body []parser.Node, limits bool) {
+	if m.operators == nil {
+		m.operators = make(map[string]operator)
+	}
+	m.operators[name] = operator{body: body, limits: limits}
+}
+
+// lookupOperator returns the operator name a command stands for
+func (m *MathProcessor) lookupOperator(cmd *parser.Command) (operator, bool) {
+	switch cmd.Name {
+	case "operatorname", "operatorname*":
+		if len(cmd.Args) == 0 {
+			return operator{}, false
+		}
+		return operator{body: cmd.Args[:1], limits: cmd.Name == "operatorname*"}, true
+	}
+
+	if op, ok := m.operators[cmd.Name]; ok {
+		return op, true
+	}
+	if text, ok := limitOperators[cmd.Name]; ok {
+		return operator{words: strings.Fields(text), limits: true}, true
+	}
+	if slices.Contains(trigonometricFunctions, cmd.Name) || slices.Contains(logarithmicFunctions, cmd.Name) ||
+		slices.Contains(functionNames, cmd.Name) {
+		return operator{words: []string{cmd.Name}}, true
+	}
+	return operator{}, false
+}
+
+// renderOperator renders an operator name upright
+func (m *MathProcessor) renderOperator(op operator, x, y float64, style mathStyle) float64 {
+	if op.body != nil {
+		return m.inAlphabet("mathrm", func() float64 {
+			return m.renderList(op.body, x, y, style)
+		})
+	}
+
+	fontSize := m.size(style)
+	currentX := x
+	for i, word := range op.words {
+		if i > 0 {
+			currentX += explicitSpaces[","] * fontSize
+		}
+		m.generator.AddText(word, currentX, y, fontSize, "normal")
+		currentX += m.generator.GetTextWidth(word, fontSize, "normal")
+	}
+	return currentX - x
+}
+
+// operatorWidth calculates the width of an operator name
+func (m *MathProcessor) operatorWidth(op operator, style mathStyle) float64 {
+	if op.body != nil {
+		return m.inAlphabet("mathrm", func() float64 {
+			return m.listWidth(op.body, style)
+		})
+	}
+
+	fontSize := m.size(style)
+	width := 0.0
+	for i, word := range op.words {
+		if i > 0 {
+			width += explicitSpaces[","] * fontSize
+		}
+		width += m.generator.GetTextWidth(word, fontSize, "normal")
+	}
+	return width
+}
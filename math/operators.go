@@ -1,44 +1,55 @@
 package math
 
-import "slices"
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
 
-// GetMathFont returns the appropriate font style for math content
+// italicLetters are the letters other than Latin ones that are set in
+// italic: lowercase Greek, as in TeX, and the dotless i and j
+const italicLetters = "αβγδεζηθικλμνξοπρστυφχψωϵϑϕϖϱςıȷ"
+
+// GetMathFont returns the font style of a math character. Latin letters
+// and lowercase Greek letters are variables, set in italic, and digits,
+// capital Greek letters and symbols are upright.
 func GetMathFont(content string) string {
-	// Use normal for now for testing
+	r, size := utf8.DecodeRuneInString(content)
+	if size == 0 || size < len(content) {
+		return "normal"
+	}
+	if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || strings.ContainsRune(italicLetters, r) {
+		return "math-italic"
+	}
 	return "normal"
 }
 
-// MathSpacing defines spacing rules for mathematical typography
+// MathSpacing defines the spaces set between math atoms, in em
 type MathSpacing struct {
-	BeforeOperator float64 // space before +, -, *, etc.
-	AfterOperator  float64 // space after +, -, *, etc.
-	BeforeRelation float64 // space before =, <, >, etc.
-	AfterRelation  float64 // space after =, <, >, etc.
-	BeforeFunction float64 // space before sin, cos, log, etc.
-	AfterFunction  float64 // space after sin, cos, log, etc.
+	ThinSpace   float64 // after operator names and commas
+	MediumSpace float64 // around binary operations such as +
+	ThickSpace  float64 // around relations such as =
 }
 
-// StandardMathSpacing returns standard mathematical spacing rules
-func StandardMathSpacing(fontSize float64) MathSpacing {
-	baseUnit := fontSize * 0.1 // 10% of font size as base spacing unit
+// StandardMathSpacing returns TeX's thin, medium and thick math spaces of
+// 3, 4 and 5 eighteenths of an em
+func StandardMathSpacing() MathSpacing {
 	return MathSpacing{
-		BeforeOperator: baseUnit * 2, // medium space
-		AfterOperator:  baseUnit * 2,
-		BeforeRelation: baseUnit * 3, // thick space
-		AfterRelation:  baseUnit * 3,
-		BeforeFunction: baseUnit * 1, // thin space
-		AfterFunction:  0,            // no space after function names
+		ThinSpace:   3.0 / 18,
+		MediumSpace: 4.0 / 18,
+		ThickSpace:  5.0 / 18,
 	}
 }
 
 // IsOperator checks if a string is a mathematical operator
 func IsOperator(s string) bool {
-	operators := []string{"+", "-", "*", "×", "÷", "·", "±", "∓"}
+	operators := []string{"+", "-", "*", "×", "÷", "·", "±", "∓", "∪", "∩", "∧", "∨", "•"}
 	return slices.Contains(operators, s)
 }
 
 // IsRelation checks if a string is a mathematical relation
 func IsRelation(s string) bool {
-	relations := []string{"=", "<", ">", "≤", "≥", "≠", "≡", "≈", "∼", "≃", "≅", "∝"}
+	relations := []string{"=", "<", ">", ":", "≤", "≥", "≠", "≡", "≈", "∼", "≃", "≅", "∝",
+		"∈", "∉", "⊂", "⊃", "⊆", "⊇", "⇒", "⇔", "←", "→", "↑", "↓", "↔", "⇐", "⇑", "⇓"}
 	return slices.Contains(relations, s)
 }
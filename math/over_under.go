@@ -0,0 +1,230 @@
+package math
+
+import (
+	"math"
+
+	"github.com/rickykimani/gotex/parser"
+)
+
+// braces are the commands that draw a brace over or under their argument
+var braces = map[string]bool{
+	"overbrace":  true,
+	"underbrace": true,
+}
+
+const (
+	// braceGap is the space between a brace and what it spans, and
+	// braceHeight how tall the brace is, in em
+	braceGap    = 0.1
+	braceHeight = 0.25
+
+	// Extensible arrows are arrowPadding em wider than what is set over
+	// and under them. arrowTop and arrowBottom are how far the arrow glyph
+	// reaches above its baseline, and arrowGap the space between the
+	// arrow and what is set over and under it.
+	arrowPadding = 0.5
+	arrowTop     = 0.527
+	arrowBottom  = 0.1
+	arrowGap     = 0.1
+
+	// The ink of the arrow glyphs and of the minus signs that lengthen
+	// them runs from arrowInkStart to arrowInkEnd and minusInkStart to
+	// minusInkEnd em along them
+	arrowInkStart = 0.05
+	arrowInkEnd   = 0.79
+	minusInkStart = 0.106
+	minusInkEnd   = 0.732
+)
+
+// arrowHeads are the extensible arrows and the glyphs of their heads
+var arrowHeads = map[string]string{
+	"xrightarrow": "→",
+	"xleftarrow":  "←",
+}
+
+// overUnderLayout is where the parts of math set over and under go,
+// relative to the position the whole is drawn at
+type overUnderLayout struct {
+	baseStyle, overStyle, underStyle mathStyle
+	baseX, overX, underX             float64
+	overY, underY                    float64
+	baseWidth, width                 float64
+	height, depth                    float64 // The extent of the base, rule, brace or arrow
+}
+
+// layoutOverUnder places a base and what is set over and under it. Rules,
+// braces and arrows are drawn as wide as the base, and an arrow as wide as
+// what is set over and under it. Math set over and under a base is placed
+// as limits are.
+func (m *MathProcessor) layoutOverUnder(n *parser.MathOverUnder, style mathStyle) overUnderLayout {
+	fontSize := m.size(style)
+	thickness := fractionRule * fontSize
+	l := overUnderLayout{baseStyle: style, overStyle: style.superscript(), underStyle: style.subscript()}
+	if n.Command == "overline" {
+		l.baseStyle = style.cramp()
+	}
+
+	if _, ok := arrowHeads[n.Command]; ok {
+		overWidth := m.calculateElementWidth(n.Over, l.overStyle)
+		underWidth := m.calculateElementWidth(n.Under, l.underStyle)
+		l.baseWidth = math.Max(math.Max(overWidth, underWidth)+arrowPadding*fontSize, (arrowInkEnd-arrowInkStart)*fontSize)
+		l.width = l.baseWidth
+		l.overX, l.underX = (l.width-overWidth)/2, (l.width-underWidth)/2
+		_, overDepth := m.elementExtent(n.Over, l.overStyle)
+		underHeight, _ := m.elementExtent(n.Under, l.underStyle)
+		l.height, l.depth = arrowTop*fontSize, 0
+		l.overY = (arrowTop+arrowGap)*fontSize + overDepth
+		l.underY = (arrowBottom-arrowGap)*fontSize - underHeight
+		return l
+	}
+
+	l.baseWidth = m.calculateElementWidth(n.Base, l.baseStyle)
+	l.height, l.depth = m.elementExtent(n.Base, l.baseStyle)
+	switch n.Command {
+	case "overline":
+		l.height += 5 * thickness
+	case "underline":
+		l.depth += 5 * thickness
+	case "overbrace":
+		l.height += (braceGap + braceHeight) * fontSize
+	case "underbrace":
+		l.depth += (braceGap + braceHeight) * fontSize
+	}
+
+	overWidth := m.calculateElementWidth(n.Over, l.overStyle)
+	underWidth := m.calculateElementWidth(n.Under, l.underStyle)
+	l.width = l.baseWidth
+	if n.Over != nil {
+		l.width = math.Max(l.width, overWidth)
+	}
+	if n.Under != nil {
+		l.width = math.Max(l.width, underWidth)
+	}
+	l.baseX = (l.width - l.baseWidth) / 2
+	l.overX, l.underX = (l.width-overWidth)/2, (l.width-underWidth)/2
+	l.underY, l.overY = m.limitShifts(l.height, l.depth, style)
+	return l
+}
+
+// renderOverUnder renders math with a rule, brace or arrow, or smaller
+// math, set over or under it
+func (m *MathProcessor) renderOverUnder(n *parser.MathOverUnder, x, y float64, style mathStyle) float64 {
+	l := m.layoutOverUnder(n, style)
+	fontSize := m.size(style)
+	thickness := fractionRule * fontSize
+	baseX, baseEnd := x+l.baseX, x+l.baseX+l.baseWidth
+
+	if head, ok := arrowHeads[n.Command]; ok {
+		m.renderArrow(head, baseX, y, l.baseWidth, fontSize)
+	} else {
+		m.renderMathElement(n.Base, baseX, y, l.baseStyle)
+	}
+
+	switch n.Command {
+	case "overline":
+		ruleY := y + l.height - 1.5*thickness
+		m.generator.AddLine(baseX, ruleY, baseEnd, ruleY)
+	case "underline":
+		ruleY := y - l.depth + 1.5*thickness
+		m.generator.AddLine(baseX, ruleY, baseEnd, ruleY)
+	case "overbrace":
+		m.renderBrace(baseX, y+l.height-braceHeight*fontSize, l.baseWidth, braceHeight*fontSize)
+	case "underbrace":
+		m.renderBrace(baseX, y-l.depth+braceHeight*fontSize, l.baseWidth, -braceHeight*fontSize)
+	}
+
+	if n.Over != nil {
+		m.renderMathElement(n.Over, x+l.overX, y+l.overY, l.overStyle)
+	}
+	if n.Under != nil {
+		m.renderMathElement(n.Under, x+l.underX, y+l.underY, l.underStyle)
+	}
+	return l.width
+}
+
+// renderArrow draws an arrow of a width: the glyph of its head, with minus
+// signs lengthening its shaft
+func (m *MathProcessor) renderArrow(head string, x, y, width, fontSize float64) {
+	step := (minusInkEnd - minusInkStart) * fontSize
+	if head == "←" {
+		m.generator.AddText(head, x-arrowInkStart*fontSize, y, fontSize, "normal")
+		headEnd := x + (arrowInkEnd-arrowInkStart)*fontSize
+		for end := x + width; end > headEnd; end -= step {
+			m.generator.AddText("−", end-minusInkEnd*fontSize, y, fontSize, "normal")
+		}
+		return
+	}
+
+	headStart := x + width - (arrowInkEnd-arrowInkStart)*fontSize
+	m.generator.AddText(head, headStart-arrowInkStart*fontSize, y, fontSize, "normal")
+	for start := x; start < headStart; start += step {
+		m.generator.AddText("−", start-minusInkStart*fontSize, y, fontSize, "normal")
+	}
+}
+
+// renderBrace draws a horizontal brace of a width from a baseline at y,
+// with its ends curling down and its tip up, or the other way round when
+// height is negative
+func (m *MathProcessor) renderBrace(x, y, width, height float64) {
+	r := math.Min(math.Abs(height)/2, width/4)
+	if height < 0 {
+		r = -r
+	}
+	middle := x + width/2
+	radius := math.Abs(r)
+
+	// Quarter circles curl the ends up to the arms and the arms up to the
+	// tip, on both sides of the middle
+	const segments = 6
+	arc := func(cx, cy, from, to float64) {
+		for i := range segments {
+			a1 := from + (to-from)*float64(i)/segments
+			a2 := from + (to-from)*float64(i+1)/segments
+			m.generator.AddLine(cx+radius*math.Cos(a1), cy+r*math.Sin(a1), cx+radius*math.Cos(a2), cy+r*math.Sin(a2))
+		}
+	}
+	arc(x+radius, y, math.Pi, math.Pi/2)
+	m.generator.AddLine(x+radius, y+r, middle-radius, y+r)
+	arc(middle-radius, y+2*r, -math.Pi/2, 0)
+	arc(middle+radius, y+2*r, math.Pi, 3*math.Pi/2)
+	m.generator.AddLine(middle+radius, y+r, x+width-radius, y+r)
+	arc(x+width-radius, y, math.Pi/2, 0)
+}
+
+// overUnderWidth calculates the width of math with something set over or
+// under it
+func (m *MathProcessor) overUnderWidth(n *parser.MathOverUnder, style mathStyle) float64 {
+	return m.layoutOverUnder(n, style).width
+}
+
+// overUnderExtent returns how far math with something set over or under
+// it reaches above and below its baseline
+func (m *MathProcessor) overUnderExtent(n *parser.MathOverUnder, style mathStyle) (float64, float64) {
+	l := m.layoutOverUnder(n, style)
+	height, depth := l.height, l.depth
+	if n.Over != nil {
+		overHeight, _ := m.elementExtent(n.Over, l.overStyle)
+		height = math.Max(height, l.overY+overHeight)
+	}
+	if n.Under != nil {
+		_, underDepth := m.elementExtent(n.Under, l.underStyle)
+		depth = math.Max(depth, underDepth-l.underY)
+	}
+	return height, depth
+}
+
+// overUnderClass returns the class of math with something set over or
+// under it. A brace makes an operator taking its label as a limit, an
+// arrow or \stackrel a relation, and \overset and \underset take the
+// class of their base.
+func (m *MathProcessor) overUnderClass(n *parser.MathOverUnder) atomClass {
+	switch {
+	case braces[n.Command]:
+		return opAtom
+	case arrowHeads[n.Command] != "", n.Command == "stackrel":
+		return relAtom
+	case n.Command == "overset", n.Command == "underset":
+		return m.nodeClass(n.Base)
+	}
+	return ordAtom
+}
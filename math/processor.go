@@ -9,6 +9,17 @@ type MathProcessor struct {
 	generator *pdf.Generator
 	fontSize  float64
 	spacing   MathSpacing
+
+	// Operator names added by \DeclareMathOperator
+	operators map[string]operator
+
+	// The alphabet of the \mathbb or similar command being set, if any
+	alphabet *mathAlphabet
+
+	// Sets the content of \text, and the style of math nested in the
+	// \text being set
+	textSetter  TextSetter
+	nestedStyle mathStyle
 }
 
 // NewMathProcessor creates a new math processor
@@ -16,6 +27,6 @@ func NewMathProcessor(generator *pdf.Generator, fontSize float64) *MathProcessor
 	return &MathProcessor{
 		generator: generator,
 		fontSize:  fontSize,
-		spacing:   StandardMathSpacing(fontSize),
+		spacing:   StandardMathSpacing(),
 	}
 }
@@ -4,17 +4,20 @@ import "github.com/rickykimani/gotex/parser"
 
 //TODO: Implement different radicals
 
-// renderSquareRoot renders square root symbols with proper vinculum
-func (mp *MathProcessor) renderSquareRoot(arg parser.Node, x, y, fontSize float64) float64 {
+// renderSquareRoot renders square root symbols with proper vinculum. The
+// radicand is set in the cramped form of the style.
+func (mp *MathProcessor) renderSquareRoot(arg parser.Node, x, y float64, style mathStyle) float64 {
+	fontSize := mp.size(style)
+
 	// Render the square root symbol
 	mp.generator.AddText("√", x, y, fontSize, "normal")
 	symbolWidth := mp.generator.GetTextWidth("√", fontSize, "normal")
 
 	// Calculate the width of the argument to know how long the vinculum should be
-	argWidth := mp.calculateElementWidth(arg, fontSize)
+	argWidth := mp.calculateElementWidth(arg, style.cramp())
 
 	// Render the argument
-	mp.renderMathElement(arg, x+symbolWidth, y, fontSize)
+	mp.renderMathElement(arg, x+symbolWidth, y, style.cramp())
 
 	// Draw the vinculum (horizontal line) above the radicand
 	// Position it at the top of the radical symbol (above the baseline)
@@ -0,0 +1,203 @@
+package math
+
+import (
+	"math"
+	"slices"
+
+	"github.com/rickykimani/gotex/parser"
+)
+
+// limitsCommands are \limits, \nolimits and \displaylimits, which the
+// parser wraps around the operator before them
+var limitsCommands = map[string]bool{
+	"limits":        true,
+	"nolimits":      true,
+	"displaylimits": true,
+}
+
+const (
+	// Gaps between an operator and its limits, in em: the least space
+	// between them and the least distance from the operator to the
+	// baseline of the limit
+	limitGap          = 0.111
+	limitBaselineGap  = 0.2
+	lowerLimitGap     = 0.167
+	lowerLimitBaseGap = 0.6
+
+	// supDrop and subDrop are how far below the top and above the bottom
+	// of a large base its superscript and subscript baselines may be, in
+	// em of the script's size
+	supDrop = 0.386
+	subDrop = 0.05
+
+	// subscriptShiftWithSup is how far a subscript is lowered when there
+	// is also a superscript, leaving room between the two
+	subscriptShiftWithSup = 0.247
+)
+
+// scriptLayout is where the parts of a base with scripts go, relative to
+// the position the whole is drawn at
+type scriptLayout struct {
+	base, sub, sup    parser.Node
+	baseX, subX, supX float64
+	subY, supY        float64
+	subStyle          mathStyle
+	supStyle          mathStyle
+	width             float64
+}
+
+// splitScripts separates a node with scripts into its base, subscript and
+// superscript, either of which may be nil, so that x_i^2 is set with both
+// scripts on x
+func splitScripts(node parser.Node) (base, sub, sup parser.Node) {
+	switch n := node.(type) {
+	case *parser.MathSubscript:
+		base, sub = n.Base, n.Index
+		if inner, ok := base.(*parser.MathSuperscript); ok {
+			base, sup = inner.Base, inner.Exponent
+		}
+	case *parser.MathSuperscript:
+		base, sup = n.Base, n.Exponent
+		if inner, ok := base.(*parser.MathSubscript); ok {
+			base, sub = inner.Base, inner.Index
+		}
+	}
+	return base, sub, sup
+}
+
+// takesLimits reports whether the scripts of an operator go under and over
+// it. Operator names such as \lim and large operators other than integrals
+// take limits in display style, and \limits and \nolimits override that.
+func (m *MathProcessor) takesLimits(base parser.Node, style mathStyle) bool {
+	placement := "displaylimits"
+	if cmd, ok := base.(*parser.Command); ok && limitsCommands[cmd.Name] && len(cmd.Args) > 0 {
+		placement, base = cmd.Name, cmd.Args[0]
+	}
+
+	// \overbrace and \underbrace take their labels as limits in every
+	// style
+	if over, ok := base.(*parser.MathOverUnder); ok && braces[over.Command] {
+		return placement != "nolimits"
+	}
+
+	takes := false
+	if cmd, ok := base.(*parser.Command); ok {
+		op, isOp := m.lookupOperator(cmd)
+		takes = isOp && (op.limits || placement == "limits")
+	} else if symbol, ok := largeOperator(base); ok {
+		takes = !slices.Contains(integrals, symbol) || placement == "limits"
+	}
+
+	switch placement {
+	case "limits":
+		return takes
+	case "nolimits":
+		return false
+	}
+	return takes && style.isDisplay()
+}
+
+// layoutScripts places a node with scripts, as limits under and over an
+// operator or beside the base
+func (m *MathProcessor) layoutScripts(node parser.Node, style mathStyle) scriptLayout {
+	base, sub, sup := splitScripts(node)
+	l := scriptLayout{base: base, sub: sub, sup: sup, subStyle: style.subscript(), supStyle: style.superscript()}
+
+	fontSize := m.size(style)
+	subSize, supSize := m.size(l.subStyle), m.size(l.supStyle)
+	baseWidth := m.calculateElementWidth(base, style)
+	subWidth := m.calculateElementWidth(sub, l.subStyle)
+	supWidth := m.calculateElementWidth(sup, l.supStyle)
+
+	if m.takesLimits(base, style) {
+		height, depth := m.operatorExtent(unwrapLimits(base), style)
+		if _, ok := unwrapLimits(base).(*parser.MathOverUnder); ok {
+			height, depth = m.elementExtent(unwrapLimits(base), style)
+		}
+		l.width = math.Max(baseWidth, math.Max(subWidth, supWidth))
+		l.baseX = (l.width - baseWidth) / 2
+		l.subX = (l.width - subWidth) / 2
+		l.supX = (l.width - supWidth) / 2
+		l.subY, l.supY = m.limitShifts(height, depth, style)
+		return l
+	}
+
+	// Scripts clear the top and bottom of a large base such as an integral
+	// or a fraction, but not those of letters
+	height, depth := 0.0, 0.0
+	if m.isBoxBase(unwrapLimits(base)) {
+		height, depth = m.elementExtent(unwrapLimits(base), style)
+	}
+	kern := 0.0
+	if symbol, ok := largeOperator(unwrapLimits(base)); ok && slices.Contains(integrals, symbol) {
+		kern = integralKern * m.operatorSize(symbol, style)
+	}
+
+	// A superscript clears the overhang of an italic base
+	l.subX = baseWidth - kern
+	l.supX = baseWidth + m.italicCorrection(base, style)
+	subShift := subscriptShift
+	if sup != nil {
+		subShift = subscriptShiftWithSup
+	}
+	l.subY = -math.Max(subShift*fontSize, depth-subDrop*subSize)
+	l.supY = math.Max(fontSize*superscriptShift(style), height-supDrop*supSize)
+	l.width = baseWidth
+	if sub != nil {
+		l.width = math.Max(l.width, l.subX+subWidth)
+	}
+	if sup != nil {
+		l.width = math.Max(l.width, l.supX+supWidth)
+	}
+	return l
+}
+
+// limitShifts returns where the baselines of limits go under and over a
+// base of a height and depth set in a style
+func (m *MathProcessor) limitShifts(height, depth float64, style mathStyle) (subY, supY float64) {
+	fontSize := m.size(style)
+	subSize, supSize := m.size(style.subscript()), m.size(style.superscript())
+	subY = -depth - math.Max(lowerLimitGap*fontSize+operatorAscent*subSize, lowerLimitBaseGap*fontSize)
+	supY = height + math.Max(limitGap*fontSize+operatorDescent*supSize, limitBaselineGap*fontSize)
+	return subY, supY
+}
+
+// isBoxBase reports whether scripts are placed by the height and depth of
+// a base, as they are on large operators, fractions and delimiters, rather
+// than at fixed shifts from the baseline
+func (m *MathProcessor) isBoxBase(base parser.Node) bool {
+	switch n := base.(type) {
+	case *parser.MathSymbol:
+		return isLargeOperator(n.Symbol)
+	case *parser.MathFraction, *parser.MathDelimited, *parser.MathAccent, *parser.MathOverUnder:
+		return true
+	case *parser.Command:
+		_, _, isFraction := commandFraction(n, textStyle)
+		_, isDelimiter := bigDelimiterScale(n.Name)
+		return isFraction || isDelimiter
+	}
+	return false
+}
+
+// unwrapLimits returns the operator a \limits or \nolimits command applies to
+func unwrapLimits(node parser.Node) parser.Node {
+	if cmd, ok := node.(*parser.Command); ok && limitsCommands[cmd.Name] && len(cmd.Args) > 0 {
+		return cmd.Args[0]
+	}
+	return node
+}
+
+// renderScripts renders a node with a subscript, superscript or both
+func (m *MathProcessor) renderScripts(node parser.Node, x, y float64, style mathStyle) float64 {
+	l := m.layoutScripts(node, style)
+	if l.base != nil {
+		m.renderMathElement(l.base, x+l.baseX, y, style)
+	}
+	if l.sub != nil {
+		m.renderMathElement(l.sub, x+l.subX, y+l.subY, l.subStyle)
+	}
+	if l.sup != nil {
+		m.renderMathElement(l.sup, x+l.supX, y+l.supY, l.supStyle)
+	}
+	return l.width
+}
@@ -0,0 +1,38 @@
+package math
+
+import (
+	"testing"
+
+	"github.com/rickykimani/gotex/parser"
+)
+
+func TestTakesLimits(t *testing.T) {
+	sum := &parser.MathSymbol{Symbol: "∑", Command: "sum"}
+	integral := &parser.MathSymbol{Symbol: "∫", Command: "int"}
+	tests := []struct {
+		name     string
+		base     parser.Node
+		style    mathStyle
+		expected bool
+	}{
+		{"sum in display", sum, displayStyle, true},
+		{"sum in text", sum, textStyle, false},
+		{"integral in display", integral, displayStyle, false},
+		{"lim in display", &parser.Command{Name: "lim"}, displayStyle, true},
+		{"sin in display", &parser.Command{Name: "sin"}, displayStyle, false},
+		{"limits on integral in text", &parser.Command{Name: "limits", Args: []parser.Node{integral}}, textStyle, true},
+		{"nolimits on sum in display", &parser.Command{Name: "nolimits", Args: []parser.Node{sum}}, displayStyle, false},
+		{"displaylimits on sum in text", &parser.Command{Name: "displaylimits", Args: []parser.Node{sum}}, textStyle, false},
+		{"variable in display", &parser.TextNode{Value: "x"}, displayStyle, false},
+		{"underbrace in text", &parser.MathOverUnder{Command: "underbrace"}, textStyle, true},
+		{"nolimits on overbrace", &parser.Command{Name: "nolimits", Args: []parser.Node{&parser.MathOverUnder{Command: "overbrace"}}}, displayStyle, false},
+		{"overline in display", &parser.MathOverUnder{Command: "overline"}, displayStyle, false},
+	}
+
+	m := &MathProcessor{fontSize: 10}
+	for _, tt := range tests {
+		if got := m.takesLimits(tt.base, tt.style); got != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
@@ -1,22 +1,49 @@
 package math
 
-import "github.com/rickykimani/gotex/parser"
-
-// getSpacing returns appropriate spacing between two math elements
-func (m *MathProcessor) getSpacing(prev, curr parser.Node) float64 {
-	// Extract text content for analysis
-	prevText := m.extractTextContent(prev)
-	currText := m.extractTextContent(curr)
-
-	// Apply spacing rules
-	if IsOperator(prevText) || IsOperator(currText) {
-		return m.spacing.BeforeOperator
-	}
+// atomSpacing is TeX's table of the space between math atoms, by the class
+// of the left atom and then of the right one: 0 for none, 2 for a thin
+// space, and 1, 3 and 4 for a thin, medium and thick space that is left
+// out at script sizes. Pairs marked * cannot occur, since a binary
+// operation next to them becomes ordinary.
+var atomSpacing = [...]string{
+	ordAtom:   "02340001",
+	opAtom:    "22*40001",
+	binAtom:   "33**3**3",
+	relAtom:   "44*04004",
+	openAtom:  "00*00000",
+	closeAtom: "02340001",
+	punctAtom: "11*11111",
+	innerAtom: "12341011",
+}
 
-	if IsRelation(prevText) || IsRelation(currText) {
-		return m.spacing.BeforeRelation
+// between returns the space between atoms of two classes in em
+func (s MathSpacing) between(left, right atomClass, script bool) float64 {
+	switch atomSpacing[left][right] {
+	case '1':
+		if !script {
+			return s.ThinSpace
+		}
+	case '2':
+		return s.ThinSpace
+	case '3':
+		if !script {
+			return s.MediumSpace
+		}
+	case '4':
+		if !script {
+			return s.ThickSpace
+		}
 	}
+	return 0
+}
 
-	// Default spacing for other elements
-	return m.fontSize * 0.1
+// explicitSpaces are the widths of the math spacing commands in em
+var explicitSpaces = map[string]float64{
+	",":     3.0 / 18,
+	":":     4.0 / 18,
+	";":     5.0 / 18,
+	"!":     -3.0 / 18,
+	" ":     1.0 / 3,
+	"quad":  1,
+	"qquad": 2,
 }
@@ -0,0 +1,99 @@
+package math
+
+// mathStyle is one of TeX's math styles: display, text, script and
+// scriptscript, each either normal or cramped. Cramped styles, used under
+// fraction bars and radicals and in subscripts, raise superscripts less.
+type mathStyle int
+
+const (
+	displayStyle mathStyle = iota
+	displayCramped
+	textStyle
+	textCramped
+	scriptStyle
+	scriptCramped
+	scriptScriptStyle
+	scriptScriptCramped
+)
+
+// styleCommands are the commands that switch the rest of a math list to a
+// style, keeping it cramped if it was
+var styleCommands = map[string]mathStyle{
+	"displaystyle":      displayStyle,
+	"textstyle":         textStyle,
+	"scriptstyle":       scriptStyle,
+	"scriptscriptstyle": scriptScriptStyle,
+}
+
+// scale is the size of a style relative to the text size
+func (s mathStyle) scale() float64 {
+	switch {
+	case s >= scriptScriptStyle:
+		return 0.5
+	case s >= scriptStyle:
+		return 0.7
+	}
+	return 1
+}
+
+// isDisplay reports whether s is display style, where limits go under and
+// over operators
+func (s mathStyle) isDisplay() bool {
+	return s <= displayCramped
+}
+
+// isScript reports whether s is a script style, where the smaller spaces
+// between atoms are left out
+func (s mathStyle) isScript() bool {
+	return s >= scriptStyle
+}
+
+// isCramped reports whether s is a cramped style
+func (s mathStyle) isCramped() bool {
+	return s%2 == 1
+}
+
+// cramp returns the cramped form of s
+func (s mathStyle) cramp() mathStyle {
+	return s | 1
+}
+
+// switchTo returns the style a style command switches s to, which is
+// uncramped whether or not s is, as in TeX
+func (s mathStyle) switchTo(target mathStyle) mathStyle {
+	return target
+}
+
+// superscript returns the style of a superscript: script style in display
+// and text style, and scriptscript style below that
+func (s mathStyle) superscript() mathStyle {
+	if s < scriptStyle {
+		return scriptStyle | s%2
+	}
+	return scriptScriptStyle | s%2
+}
+
+// subscript returns the style of a subscript, which is always cramped
+func (s mathStyle) subscript() mathStyle {
+	return s.superscript().cramp()
+}
+
+// numerator returns the style of a fraction's numerator, one step smaller
+// than the fraction down to scriptscript style
+func (s mathStyle) numerator() mathStyle {
+	if s < scriptScriptStyle {
+		return s + 2
+	}
+	return s
+}
+
+// denominator returns the style of a fraction's denominator, which is the
+// cramped form of the numerator's
+func (s mathStyle) denominator() mathStyle {
+	return s.numerator().cramp()
+}
+
+// size returns the font size of a style
+func (m *MathProcessor) size(s mathStyle) float64 {
+	return m.fontSize * s.scale()
+}
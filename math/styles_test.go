@@ -0,0 +1,36 @@
+package math
+
+import "testing"
+
+func TestMathStyles(t *testing.T) {
+	tests := []struct {
+		name     string
+		got      mathStyle
+		expected mathStyle
+	}{
+		{"superscript of display", displayStyle.superscript(), scriptStyle},
+		{"superscript of cramped text", textCramped.superscript(), scriptCramped},
+		{"superscript of script", scriptStyle.superscript(), scriptScriptStyle},
+		{"subscript of text", textStyle.subscript(), scriptCramped},
+		{"subscript of scriptscript", scriptScriptStyle.subscript(), scriptScriptCramped},
+		{"numerator of display", displayStyle.numerator(), textStyle},
+		{"numerator of text", textStyle.numerator(), scriptStyle},
+		{"numerator of scriptscript", scriptScriptStyle.numerator(), scriptScriptStyle},
+		{"denominator of display", displayStyle.denominator(), textCramped},
+		{"displaystyle in cramped script", scriptCramped.switchTo(displayStyle), displayStyle},
+		{"textstyle in display", displayStyle.switchTo(textStyle), textStyle},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.expected {
+			t.Errorf("%s: expected style %d, got %d", tt.name, tt.expected, tt.got)
+		}
+	}
+
+	m := &MathProcessor{fontSize: 10}
+	for style, size := range map[mathStyle]float64{displayStyle: 10, textCramped: 10, scriptStyle: 7, scriptScriptCramped: 5} {
+		if got := m.size(style); got != size {
+			t.Errorf("Style %d: expected size %.1f, got %.1f", style, size, got)
+		}
+	}
+}
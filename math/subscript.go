@@ -2,20 +2,12 @@ package math
 
 import "github.com/rickykimani/gotex/parser"
 
-// renderSubscript renders subscript (indices)
-func (mp *MathProcessor) renderSubscript(sub *parser.MathSubscript, x, y, fontSize float64) float64 {
-	// Render base
-	var baseWidth float64
-	if sub.Base != nil {
-		baseWidth = mp.renderMathElement(sub.Base, x, y, fontSize)
-	}
-
-	// Render index (smaller and lowered)
-	idxFontSize := fontSize * 0.7 // 70% of base size
-	idxY := y - fontSize*0.3      // LOWER by 30%
-	mp.renderMathElement(sub.Index, x+baseWidth, idxY, idxFontSize)
-
-	// Calculate total width
-	idxWidth := mp.calculateElementWidth(sub.Index, idxFontSize)
-	return baseWidth + idxWidth
+// subscriptShift is how far a subscript is lowered, in em of the style of
+// its base
+const subscriptShift = 0.15
+
+// renderSubscript renders subscript (indices), or the lower limit of an
+// operator such as \sum in display style
+func (mp *MathProcessor) renderSubscript(sub *parser.MathSubscript, x, y float64, style mathStyle) float64 {
+	return mp.renderScripts(sub, x, y, style)
 }
@@ -2,20 +2,20 @@ package math
 
 import "github.com/rickykimani/gotex/parser"
 
-// renderSuperscript renders superscript (exponents)
-func (mp *MathProcessor) renderSuperscript(sup *parser.MathSuperscript, x, y, fontSize float64) float64 {
-	// Render base
-	var baseWidth float64
-	if sup.Base != nil {
-		baseWidth = mp.renderMathElement(sup.Base, x, y, fontSize)
+// superscriptShift is how far a superscript is raised, in em of the style
+// of its base: most in display style and least in cramped styles
+func superscriptShift(style mathStyle) float64 {
+	switch {
+	case style.isCramped():
+		return 0.289
+	case style.isDisplay():
+		return 0.413
 	}
+	return 0.363
+}
 
-	// Render exponent (smaller and raised)
-	expFontSize := fontSize * 0.7 // 70% of base size
-	expY := y + fontSize*0.3      // RAISE by 30%
-	mp.renderMathElement(sup.Exponent, x+baseWidth, expY, expFontSize)
-
-	// Calculate total width
-	expWidth := mp.calculateElementWidth(sup.Exponent, expFontSize)
-	return baseWidth + expWidth
+// renderSuperscript renders superscript (exponents), or the upper limit of
+// an operator such as \sum in display style
+func (mp *MathProcessor) renderSuperscript(sup *parser.MathSuperscript, x, y float64, style mathStyle) float64 {
+	return mp.renderScripts(sup, x, y, style)
 }
@@ -1,21 +1,139 @@
 package math
 
 import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
 	"github.com/rickykimani/gotex/parser"
-	"github.com/rickykimani/gotex/symbols"
 )
 
-// extractTextContent extracts text content from a node for spacing analysis
-func (m *MathProcessor) extractTextContent(node parser.Node) string {
+// textAtoms splits math text into atoms: numbers, and each letter and
+// other character on its own, so that a run of letters such as xy is set
+// as a product of variables. Spaces only separate atoms.
+func textAtoms(text *parser.TextNode) []atom {
+	var atoms []atom
+	var run strings.Builder
+
+	flush := func() {
+		if run.Len() > 0 {
+			atoms = append(atoms, atom{node: &parser.TextNode{Value: run.String(), Position: text.Position}, class: ordAtom})
+			run.Reset()
+		}
+	}
+
+	for _, r := range text.Value {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+		case unicode.IsDigit(r) || r == '.':
+			run.WriteRune(r)
+		default:
+			flush()
+			atoms = append(atoms, atom{node: &parser.TextNode{Value: string(r), Position: text.Position}, class: symbolClass(string(r))})
+		}
+	}
+	flush()
+
+	return atoms
+}
+
+// textCommands are the commands that set text in math, such as \text,
+// with the font style of each
+var textCommands = map[string]string{
+	"text":       "normal",
+	"textrm":     "normal",
+	"textnormal": "normal",
+	"mbox":       "normal",
+	"textbf":     "bold",
+	"textit":     "italic",
+	"texttt":     "mono",
+}
+
+// TextSetter sets the content of \text and similar commands, which is
+// text, in a font style at a size. It draws it at x and y if draw is set
+// and returns its width.
+type TextSetter func(nodes []parser.Node, style string, x, y, size float64, draw bool) float64
+
+// SetTextSetter sets how the content of \text and similar commands is set
+func (m *MathProcessor) SetTextSetter(setter TextSetter) {
+	m.textSetter = setter
+}
+
+// renderText renders text in math in the face of its command, at the size
+// of the style
+func (m *MathProcessor) renderText(text *parser.MathText, x, y float64, style mathStyle) float64 {
+	return m.setText(text, x, y, style, true)
+}
+
+// textWidth calculates the width of text in math, spaces included
+func (m *MathProcessor) textWidth(text *parser.MathText, style mathStyle) float64 {
+	return m.setText(text, 0, 0, style, false)
+}
+
+// setText sets text in math with the text setter. Math nested in the text
+// is in no alphabet and starts afresh, uncramped, in text style or in the
+// script style of the text's size.
+func (m *MathProcessor) setText(text *parser.MathText, x, y float64, style mathStyle, draw bool) float64 {
+	if m.textSetter == nil {
+		return 0
+	}
+	savedStyle, savedAlphabet := m.nestedStyle, m.alphabet
+	m.nestedStyle, m.alphabet = max(style&^1, textStyle), nil
+	defer func() { m.nestedStyle, m.alphabet = savedStyle, savedAlphabet }()
+	return m.textSetter(text.Content, textCommands[text.Command], x, y, m.size(style), draw)
+}
+
+// NestedMath sets math in the text of \text or a similar command, such as
+// the $y$ of \text{if $y$}, in the style setText chose for it. It
+// draws it at x and y if draw is set and returns its width.
+func (m *MathProcessor) NestedMath(node *parser.MathNode, x, y float64, draw bool) float64 {
+	if draw {
+		return m.renderList(node.Content, x, y, m.nestedStyle)
+	}
+	return m.listWidth(node.Content, m.nestedStyle)
+}
+
+// italicCorrections are how far the italic letters that lean out of their
+// box reach past its right side, in em. That much space is added after
+// them before upright math, so that f(x) does not run f into (.
+var italicCorrections = map[rune]float64{
+	'f': 0.13, 'K': 0.07, 'T': 0.07, 'Y': 0.07, 'V': 0.06, 'r': 0.05, 'ζ': 0.05,
+	'γ': 0.04, 'k': 0.03, 't': 0.03, 'W': 0.03, 'σ': 0.03, 'χ': 0.03, 'z': 0.02,
+	'X': 0.02, 'Z': 0.02, 'ψ': 0.02, 'ϖ': 0.02, 'F': 0.01, 'v': 0.01, 'x': 0.01,
+	'y': 0.01, 'π': 0.01,
+}
+
+// isItalic reports whether a font style is one of the italic math faces
+func isItalic(style string) bool {
+	return style == "math-italic" || style == "math-bold-italic"
+}
+
+// startsItalic reports whether math starts with an italic letter
+func (m *MathProcessor) startsItalic(node parser.Node) bool {
 	switch n := node.(type) {
 	case *parser.TextNode:
-		return n.Value
-	case *parser.Command:
-		if symbol, exists := symbols.ConvertMathSymbol(n.Name); exists {
-			return symbol
-		}
-		return n.Name
-	default:
-		return ""
+		r, _ := utf8.DecodeRuneInString(strings.TrimSpace(n.Value))
+		return isItalic(m.textRuns(string(r))[0].style)
+	case *parser.MathSymbol:
+		return !isLargeOperator(n.Symbol) && n.Symbol != "" && isItalic(m.textRuns(n.Symbol)[0].style)
+	case *parser.MathSuperscript:
+		return m.startsItalic(n.Base)
+	case *parser.MathSubscript:
+		return m.startsItalic(n.Base)
+	case *parser.Group:
+		return len(n.Nodes) > 0 && m.startsItalic(n.Nodes[0])
+	}
+	return false
+}
+
+// italicCorrection returns the space added after an italic letter set in a
+// style before upright math
+func (m *MathProcessor) italicCorrection(node parser.Node, style mathStyle) float64 {
+	char, ok := singleCharacter(node)
+	if !ok || !isItalic(m.textRuns(char)[0].style) {
+		return 0
 	}
+	r, _ := utf8.DecodeRuneInString(char)
+	return italicCorrections[r] * m.size(style)
 }
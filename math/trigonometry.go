@@ -1,5 +1,9 @@
 package math
 
-//TODO: Implement trig functions \sin etc.
-
-//TODO: Implement inverse trig functions \arcsin etc
+// trigonometricFunctions are the operator names of the circular and
+// hyperbolic functions and the inverse circular functions
+var trigonometricFunctions = []string{
+	"sin", "cos", "tan", "cot", "sec", "csc",
+	"arcsin", "arccos", "arctan",
+	"sinh", "cosh", "tanh", "coth",
+}
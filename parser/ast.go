@@ -15,6 +15,8 @@ type Command struct {
 
 type Environment struct {
 	Name     string
+	Args     []Node // Required {...} arguments after \begin{name}
+	Optional []Node // Optional [...] argument after \begin{name}
 	Body     []Node
 	Position lexer.Position
 }
@@ -24,6 +26,13 @@ type TextNode struct {
 	Position lexer.Position
 }
 
+// VerbatimNode holds text captured by the lexer without tokenisation,
+// from \verb|...| or the body of a verbatim environment
+type VerbatimNode struct {
+	Value    string
+	Position lexer.Position
+}
+
 type MathNode struct {
 	Inline   bool
 	Content  []Node
@@ -87,10 +96,58 @@ type MathFraction struct {
 	Position    lexer.Position
 }
 
+// MathDelimited is math between \left and \right, whose delimiters grow
+// to the height of what they enclose. Content may hold \middle commands.
+type MathDelimited struct {
+	Left     string // The opening delimiter, such as ( or ⟨; empty for \left.
+	Right    string // The closing delimiter; empty for \right.
+	Content  []Node
+	Position lexer.Position
+}
+
+// MathArray is a math environment set in rows and columns, such as pmatrix
+// or cases. Each cell is a Group.
+type MathArray struct {
+	Name     string // The environment, such as pmatrix
+	Rows     [][]Node
+	Position lexer.Position
+}
+
+// MathAccent is math with an accent over it, such as \hat{x}. The accent
+// of a wide accent such as \widehat stretches across the base.
+type MathAccent struct {
+	Command  string // The accent command, such as hat or widetilde
+	Accent   string // The accent character, such as ˆ
+	Wide     bool
+	Base     Node
+	Position lexer.Position
+}
+
+// MathOverUnder is math with something set over or under it: a rule, brace
+// or arrow drawn to its width, as by \overline, \underbrace and
+// \xrightarrow, or smaller math, as by \overset and \stackrel
+type MathOverUnder struct {
+	Command  string // The command, such as overbrace or overset
+	Base     Node   // The math the rest is set over or under; nil for arrows
+	Over     Node   // What is set over the base, if anything
+	Under    Node   // What is set under the base, if anything
+	Position lexer.Position
+}
+
+// MathText is text in math, such as \text{if}, which is set as text in
+// an upright text face with its spaces kept. Its content is text as in a
+// paragraph, with commands and math of its own.
+type MathText struct {
+	Command  string // The command, such as text or textbf
+	Content  []Node
+	Position lexer.Position
+}
+
 // Implement the Node interface for all AST nodes
 func (c *Command) Pos() lexer.Position             { return c.Position }
 func (e *Environment) Pos() lexer.Position         { return e.Position }
 func (t *TextNode) Pos() lexer.Position            { return t.Position }
+func (v *VerbatimNode) Pos() lexer.Position        { return v.Position }
 func (m *MathNode) Pos() lexer.Position            { return m.Position }
 func (d *Document) Pos() lexer.Position            { return d.Position }
 func (c *CommentNode) Pos() lexer.Position         { return c.Position }
@@ -102,6 +159,11 @@ func (m *MathOperator) Pos() lexer.Position        { return m.Position }
 func (m *MathSuperscript) Pos() lexer.Position     { return m.Position }
 func (m *MathSubscript) Pos() lexer.Position       { return m.Position }
 func (m *MathFraction) Pos() lexer.Position        { return m.Position }
+func (m *MathDelimited) Pos() lexer.Position       { return m.Position }
+func (m *MathArray) Pos() lexer.Position           { return m.Position }
+func (m *MathAccent) Pos() lexer.Position          { return m.Position }
+func (m *MathOverUnder) Pos() lexer.Position       { return m.Position }
+func (m *MathText) Pos() lexer.Position            { return m.Position }
 
 // NewDocument creates a new document with synchronized fields
 func NewDocument(nodes []Node, pos lexer.Position) *Document {
@@ -22,6 +22,22 @@ func (p *Parser) parseCommand() *Command {
 		return cmd
 	}
 
+	// \verb carries its raw argument as a verbatim token instead of braces
+	if (cmd.Name == "verb" || cmd.Name == "verb*") && p.peekToken.Type == lexer.TokenVerbatim {
+		p.nextToken()
+		cmd.Args = append(cmd.Args, p.parseVerbatim())
+		return cmd
+	}
+
+	// The URL of \url and \href is also raw; \href's link text follows as usual
+	if (cmd.Name == "url" || cmd.Name == "href") && p.peekToken.Type == lexer.TokenVerbatim {
+		p.nextToken()
+		cmd.Args = append(cmd.Args, p.parseVerbatim())
+		if cmd.Name == "url" {
+			return cmd
+		}
+	}
+
 	// Look ahead for optional arguments [...]
 	if p.peekToken.Type == lexer.TokenOptionalArg {
 		p.nextToken() // move to optional arg token
@@ -47,3 +63,32 @@ func (p *Parser) parseCommand() *Command {
 
 	return cmd
 }
+
+// parseControlSymbol parses a control symbol such as \% or \, into a
+// command named by its character. Control symbols take no arguments, except
+// that \\ may be followed by the space to leave below the line. A closing
+// math delimiter here has no opening one, since the lexer reads math whole.
+func (p *Parser) parseControlSymbol() Node {
+	if p.curToken.Value == ")" || p.curToken.Value == "]" {
+		opening := map[string]string{")": "(", "]": "["}[p.curToken.Value]
+		p.addError(UnmatchedMath, "unexpected '\\"+p.curToken.Value+"' - no matching '\\"+opening+"'", Error)
+		return nil
+	}
+
+	cmd := &Command{
+		Name:     p.curToken.Value,
+		Args:     []Node{},
+		Optional: []Node{},
+		Position: p.curToken.Pos,
+	}
+
+	if (cmd.Name == "\\" || cmd.Name == "\\*") && p.peekToken.Type == lexer.TokenOptionalArg {
+		p.nextToken()
+		cmd.Optional = append(cmd.Optional, &TextNode{
+			Value:    p.curToken.Value,
+			Position: p.curToken.Pos,
+		})
+	}
+
+	return cmd
+}
@@ -7,6 +7,22 @@ import (
 	"github.com/rickykimani/gotex/lexer"
 )
 
+// environmentArgCounts lists environments that take required {...}
+// arguments directly after \begin{name}
+var environmentArgCounts = map[string]int{
+	"minted":          1,
+	"multicols":       1,
+	"multicols*":      1,
+	"thebibliography": 1,
+}
+
+// environmentTrailingOptional lists environments whose optional [...]
+// argument comes after the required ones
+var environmentTrailingOptional = map[string]bool{
+	"multicols":  true,
+	"multicols*": true,
+}
+
 func (p *Parser) parseEnvironment() *Environment {
 	env := &Environment{
 		Name:     p.curToken.Value,
@@ -14,6 +30,8 @@ func (p *Parser) parseEnvironment() *Environment {
 		Position: p.curToken.Pos,
 	}
 
+	p.parseEnvironmentArgs(env)
+
 	// Check if this is a math environment that needs special parsing
 	if p.isMathEnvironment(env.Name) {
 		return p.parseMathEnvironment(env)
@@ -62,9 +80,44 @@ func (p *Parser) parseEnvironment() *Environment {
 	return env
 }
 
+// parseEnvironmentArgs reads the optional [...] argument and any required
+// {...} arguments that follow \begin{name}
+func (p *Parser) parseEnvironmentArgs(env *Environment) {
+	if p.peekToken.Type == lexer.TokenOptionalArg && !environmentTrailingOptional[env.Name] {
+		p.nextToken()
+		env.Optional = append(env.Optional, &TextNode{
+			Value:    p.curToken.Value,
+			Position: p.curToken.Pos,
+		})
+	}
+
+	for range environmentArgCounts[env.Name] {
+		if p.peekToken.Type != lexer.TokenLBrace {
+			p.addError(MissingArgument,
+				fmt.Sprintf("missing argument for environment %s", env.Name), Error)
+			return
+		}
+		p.nextToken()              // move to {
+		bracePos := p.curToken.Pos // Save the position of the opening brace
+		p.nextToken()              // move past {
+
+		if arg := p.parseArgumentWithStartPos(bracePos); arg != nil {
+			env.Args = append(env.Args, arg)
+		}
+	}
+
+	if p.peekToken.Type == lexer.TokenOptionalArg && environmentTrailingOptional[env.Name] {
+		p.nextToken()
+		env.Optional = append(env.Optional, &TextNode{
+			Value:    p.curToken.Value,
+			Position: p.curToken.Pos,
+		})
+	}
+}
+
 // isMathEnvironment checks if an environment should be parsed as math content
 func (p *Parser) isMathEnvironment(name string) bool {
-	mathEnvironments := []string{"equation", "align", "gather", "multline", "split"}
+	mathEnvironments := []string{"equation", "align", "align*", "gather", "gather*", "multline", "multline*", "split"}
 	return slices.Contains(mathEnvironments, name)
 }
 
@@ -118,6 +171,8 @@ func (p *Parser) parseMathEnvironmentNode() Node {
 	switch p.curToken.Type {
 	case lexer.TokenCommand:
 		return p.parseCommand()
+	case lexer.TokenControlSymbol:
+		return p.parseControlSymbol()
 	case lexer.TokenBeginEnv:
 		return p.parseEnvironment()
 	case lexer.TokenText:
@@ -126,6 +181,8 @@ func (p *Parser) parseMathEnvironmentNode() Node {
 		return p.parseMath()
 	case lexer.TokenComment:
 		return p.parseComment()
+	case lexer.TokenVerbatim:
+		return p.parseVerbatim()
 	case lexer.TokenLBrace:
 		// In math environments, braces create groups
 		return p.parseGroup()
@@ -33,6 +33,21 @@ func TestErrorHandling(t *testing.T) {
 			input:    `\textbf{Bold \begin{center} text \end{wrong}`,
 			expected: 3, // Wrong environment end + Missing environment end + Missing brace
 		},
+		{
+			name:     "Unclosed inline math",
+			input:    "Text \\(a + b\n\nNext paragraph",
+			expected: 1,
+		},
+		{
+			name:     "Unmatched display math end",
+			input:    `Text \] and \[x\]`,
+			expected: 1,
+		},
+		{
+			name:     "Unclosed math environment",
+			input:    `\begin{equation*} x = 1`,
+			expected: 1,
+		},
 		{
 			name:     "Valid document",
 			input:    `\documentclass{article}\begin{document}Hello\end{document}`,
@@ -1,6 +1,10 @@
 package parser
 
 import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
 	"github.com/rickykimani/gotex/lexer"
 	"github.com/rickykimani/gotex/symbols"
 )
@@ -11,6 +15,14 @@ func (p *Parser) parseMath() *MathNode {
 	isInline := p.curToken.Type == lexer.TokenMathInline
 	rawContent := p.curToken.Value
 
+	if p.curToken.Unterminated {
+		kind := "display"
+		if isInline {
+			kind = "inline"
+		}
+		p.addError(UnmatchedMath, "missing closing delimiter for "+kind+" math", Error)
+	}
+
 	// The recursive math parser starts here.
 	nodes, _ := p.parseMathExpression(rawContent, 0, p.curToken.Pos)
 
@@ -21,6 +33,18 @@ func (p *Parser) parseMath() *MathNode {
 	}
 }
 
+// ParseMath parses math source found outside math delimiters, such as the
+// body of an equation environment
+func ParseMath(text string, inline bool, pos lexer.Position) *MathNode {
+	p := &Parser{}
+	nodes, _ := p.parseMathExpression(text, 0, pos)
+	return &MathNode{
+		Inline:   inline,
+		Content:  nodes,
+		Position: pos,
+	}
+}
+
 // parseMathExpression is the core of the math parser.
 // It parses a string of math content and returns a list of nodes.
 func (p *Parser) parseMathExpression(text string, startPos int, tokenPos lexer.Position) ([]Node, int) {
@@ -38,6 +62,16 @@ func (p *Parser) parseMathExpression(text string, startPos int, tokenPos lexer.P
 				nodes = nodes[:len(nodes)-1] // Pop the base from the node list
 			}
 
+			// A script applies to the character before it, not to all the
+			// text before it
+			if run, ok := base.(*TextNode); ok {
+				value := strings.TrimRightFunc(run.Value, unicode.IsSpace)
+				if _, size := utf8.DecodeLastRuneInString(value); size < len(value) {
+					nodes = append(nodes, &TextNode{Value: value[:len(value)-size], Position: run.Position})
+					base = &TextNode{Value: value[len(value)-size:], Position: run.Position}
+				}
+			}
+
 			// The script content parser will handle single chars or {...}
 			scriptContent, newPos := p.parseScriptContent(text, pos+1, tokenPos)
 			pos = newPos
@@ -54,6 +88,11 @@ func (p *Parser) parseMathExpression(text string, startPos int, tokenPos lexer.P
 			pos = newPos
 			nodes = append(nodes, bracedContent)
 
+		case '}':
+			// A brace that closes no group is left out
+			p.addErrorAtPosition(UnmatchedBrace, "unexpected '}' - no matching '{'", Warning, tokenPos)
+			pos++
+
 		case '\\':
 			// Handle LaTeX commands like \alpha or \frac
 			cmdStart := pos + 1
@@ -61,14 +100,39 @@ func (p *Parser) parseMathExpression(text string, startPos int, tokenPos lexer.P
 			for pos < len(text) && isAlpha(text[pos]) {
 				pos++
 			}
+			// \operatorname* is the form of \operatorname with limits
+			if text[cmdStart:pos] == "operatorname" && pos < len(text) && text[pos] == '*' {
+				pos++
+			}
 			cmdName := text[cmdStart:pos]
 
+			// A control symbol such as \{ or \, is named by the one character
+			// after the backslash, and escaped characters stand for themselves
+			if cmdName == "" && pos < len(text) {
+				pos++
+				cmdName = text[cmdStart:pos]
+				if strings.Contains("{}%$&#_", cmdName) {
+					nodes = append(nodes, &MathSymbol{Symbol: cmdName, Command: cmdName, Position: tokenPos})
+					continue
+				}
+			}
+
 			if symbol, exists := symbols.ConvertMathSymbol(cmdName); exists {
 				nodes = append(nodes, &MathSymbol{Symbol: symbol, Command: cmdName, Position: tokenPos})
+			} else if accent, exists := symbols.ConvertMathAccent(cmdName); exists {
+				base, newPos := p.parseMathArgument(text, pos, tokenPos)
+				pos = newPos
+				nodes = append(nodes, &MathAccent{
+					Command:  cmdName,
+					Accent:   accent,
+					Wide:     strings.HasPrefix(cmdName, "wide"),
+					Base:     base,
+					Position: tokenPos,
+				})
 			} else {
 				// Handle commands that might have arguments
 				switch cmdName {
-				case "frac":
+				case "frac", "dfrac", "tfrac":
 					// Parse two arguments for fraction
 					if pos < len(text) && text[pos] == '{' {
 						numerator, newPos := p.parseBracedMathExpression(text, pos, tokenPos)
@@ -78,11 +142,20 @@ func (p *Parser) parseMathExpression(text string, startPos int, tokenPos lexer.P
 							denominator, newPos := p.parseBracedMathExpression(text, pos, tokenPos)
 							pos = newPos
 
-							nodes = append(nodes, &MathFraction{
-								Numerator:   numerator,
-								Denominator: denominator,
-								Position:    tokenPos,
-							})
+							if cmdName == "frac" {
+								nodes = append(nodes, &MathFraction{
+									Numerator:   numerator,
+									Denominator: denominator,
+									Position:    tokenPos,
+								})
+							} else {
+								// \dfrac and \tfrac keep their name, which sets the style
+								nodes = append(nodes, &Command{
+									Name:     cmdName,
+									Args:     []Node{numerator, denominator},
+									Position: tokenPos,
+								})
+							}
 						} else {
 							// Missing second argument, treat as regular command
 							nodes = append(nodes, &Command{Name: cmdName, Position: tokenPos})
@@ -94,8 +167,8 @@ func (p *Parser) parseMathExpression(text string, startPos int, tokenPos lexer.P
 						// Missing arguments, treat as regular command
 						nodes = append(nodes, &Command{Name: cmdName, Position: tokenPos})
 					}
-				case "sqrt":
-					// Parse one argument for square root
+				case "sqrt", "operatorname", "operatorname*":
+					// Parse the one argument of a square root or operator name
 					if pos < len(text) && text[pos] == '{' {
 						argument, newPos := p.parseBracedMathExpression(text, pos, tokenPos)
 						pos = newPos
@@ -109,6 +182,106 @@ func (p *Parser) parseMathExpression(text string, startPos int, tokenPos lexer.P
 						// Missing argument, treat as regular command
 						nodes = append(nodes, &Command{Name: cmdName, Position: tokenPos})
 					}
+				case "mathbb", "mathcal", "mathfrak", "mathbf", "mathrm", "mathsf", "mathtt", "mathit",
+					"boldsymbol", "bm":
+					// The argument is set in the alphabet the command names
+					argument, newPos := p.parseMathArgument(text, pos, tokenPos)
+					pos = newPos
+					nodes = append(nodes, &Command{Name: cmdName, Args: []Node{argument}, Position: tokenPos})
+				case "text", "textrm", "textnormal", "mbox", "textbf", "textit", "texttt":
+					// The argument is text, which may hold math of its own
+					argument, newPos := p.parseTextArgument(text, pos, tokenPos)
+					pos = newPos
+					nodes = append(nodes, &MathText{Command: cmdName, Content: argument, Position: tokenPos})
+				case "overline", "underline", "overbrace", "underbrace":
+					// The rule or brace is drawn to the width of the argument
+					base, newPos := p.parseMathArgument(text, pos, tokenPos)
+					pos = newPos
+					nodes = append(nodes, &MathOverUnder{Command: cmdName, Base: base, Position: tokenPos})
+				case "overset", "underset", "stackrel":
+					// The first argument is set over or under the second
+					first, newPos := p.parseMathArgument(text, pos, tokenPos)
+					base, newPos := p.parseMathArgument(text, newPos, tokenPos)
+					pos = newPos
+					node := &MathOverUnder{Command: cmdName, Base: base, Over: first, Position: tokenPos}
+					if cmdName == "underset" {
+						node.Over, node.Under = nil, first
+					}
+					nodes = append(nodes, node)
+				case "xrightarrow", "xleftarrow":
+					// The arrow stretches under its argument and over the
+					// optional one
+					node := &MathOverUnder{Command: cmdName, Position: tokenPos}
+					if under, end, ok := readOptionalArgument(text, pos); ok {
+						content, _ := p.parseMathExpression(under, 0, tokenPos)
+						node.Under = &Group{Nodes: content, Position: tokenPos}
+						pos = end
+					}
+					node.Over, pos = p.parseMathArgument(text, pos, tokenPos)
+					nodes = append(nodes, node)
+				case "left":
+					// The content runs to the matching \right, with any pairs
+					// nested in it
+					left, contentStart := parseDelimiter(text, pos)
+					contentEnd, right := len(text), ""
+					pos = len(text)
+					if end := findRight(text, contentStart); end >= 0 {
+						contentEnd = end
+						right, pos = parseDelimiter(text, end+len(`\right`))
+					} else {
+						p.addErrorAtPosition(UnmatchedMath, "missing \\right for \\left", Error, tokenPos)
+					}
+					content, _ := p.parseMathExpression(text[contentStart:contentEnd], 0, tokenPos)
+					nodes = append(nodes, &MathDelimited{Left: left, Right: right, Content: content, Position: tokenPos})
+				case "right":
+					// A \right without a \left is dropped with its delimiter
+					_, pos = parseDelimiter(text, pos)
+					p.addErrorAtPosition(UnmatchedMath, "unexpected \\right - no matching \\left", Error, tokenPos)
+				case "middle", "big", "Big", "bigg", "Bigg",
+					"bigl", "Bigl", "biggl", "Biggl",
+					"bigr", "Bigr", "biggr", "Biggr",
+					"bigm", "Bigm", "biggm", "Biggm":
+					// The delimiter is the one argument
+					delimiter, newPos := parseDelimiter(text, pos)
+					pos = newPos
+					nodes = append(nodes, &Command{
+						Name:     cmdName,
+						Args:     []Node{&MathSymbol{Symbol: delimiter, Position: tokenPos}},
+						Position: tokenPos,
+					})
+				case "begin":
+					// Environments such as pmatrix run to their \end and are
+					// split into rows and cells
+					name, bodyStart := readEnvironmentName(text, pos)
+					if name == "" {
+						nodes = append(nodes, &Command{Name: cmdName, Position: tokenPos})
+						break
+					}
+					bodyEnd, end := findEnd(text, bodyStart, name)
+					if bodyEnd < 0 {
+						p.addErrorAtPosition(UnmatchedEnvironment, "missing \\end{"+name+"}", Error, tokenPos)
+						bodyEnd, end = len(text), len(text)
+					}
+					nodes = append(nodes, &MathArray{
+						Name:     name,
+						Rows:     p.parseArrayRows(text[bodyStart:bodyEnd], tokenPos),
+						Position: tokenPos,
+					})
+					pos = end
+				case "end":
+					// An \end without a \begin is dropped with its name
+					name, end := readEnvironmentName(text, pos)
+					p.addErrorAtPosition(UnmatchedEnvironment, "unexpected \\end{"+name+"}", Error, tokenPos)
+					pos = end
+				case "limits", "nolimits", "displaylimits":
+					// These apply to the operator before them, which they wrap
+					// so that its scripts can be placed accordingly
+					cmd := &Command{Name: cmdName, Position: tokenPos}
+					if len(nodes) > 0 {
+						cmd.Args = []Node{nodes[len(nodes)-1]}
+						nodes = nodes[:len(nodes)-1]
+					}
+					nodes = append(nodes, cmd)
 				default:
 					// For other unknown commands, just store by name
 					nodes = append(nodes, &Command{Name: cmdName, Position: tokenPos})
@@ -121,8 +294,9 @@ func (p *Parser) parseMathExpression(text string, startPos int, tokenPos lexer.P
 			for pos < len(text) && !isMathSpecialChar(text[pos]) {
 				pos++
 			}
+			// Spaces between math elements are ignored
 			value := text[textStart:pos]
-			if value != "" {
+			if strings.TrimSpace(value) != "" {
 				nodes = append(nodes, &TextNode{Value: value, Position: tokenPos})
 			}
 		}
@@ -142,10 +316,177 @@ func (p *Parser) parseScriptContent(text string, startPos int, tokenPos lexer.Po
 		return p.parseBracedMathExpression(text, startPos, tokenPos)
 	}
 
+	// Content is a single command, e.g., ^\infty
+	if text[startPos] == '\\' {
+		end := startPos + 1
+		for end < len(text) && isAlpha(text[end]) {
+			end++
+		}
+		if end == startPos+1 && end < len(text) {
+			end++
+		}
+		nodes, _ := p.parseMathExpression(text[startPos:end], 0, tokenPos)
+		if len(nodes) == 1 {
+			return nodes[0], end
+		}
+		return &Group{Nodes: nodes, Position: tokenPos}, end
+	}
+
 	// Content is a single character, e.g., ^2
 	return &TextNode{Value: string(text[startPos]), Position: tokenPos}, startPos + 1
 }
 
+// parseMathArgument parses the argument of a command such as \hat, which
+// is a braced group, a command or a single character after any spaces
+func (p *Parser) parseMathArgument(text string, startPos int, tokenPos lexer.Position) (Node, int) {
+	for startPos < len(text) && unicode.IsSpace(rune(text[startPos])) {
+		startPos++
+	}
+	return p.parseScriptContent(text, startPos, tokenPos)
+}
+
+// parseTextArgument parses the argument of a command such as \text after
+// any spaces: the text of a braced group, or else a single character
+func (p *Parser) parseTextArgument(text string, pos int, tokenPos lexer.Position) ([]Node, int) {
+	for pos < len(text) && unicode.IsSpace(rune(text[pos])) {
+		pos++
+	}
+	if pos >= len(text) {
+		return nil, pos
+	}
+	if text[pos] != '{' {
+		_, size := utf8.DecodeRuneInString(text[pos:])
+		return []Node{&TextNode{Value: text[pos : pos+size], Position: tokenPos}}, pos + size
+	}
+
+	end := findClosingBrace(text, pos)
+	return p.parseTextInMath(text[pos+1:end], tokenPos), min(end+1, len(text))
+}
+
+// parseTextInMath parses text in math, such as the argument of \text, as
+// it is written, spaces included. Escaped characters such as \% stand for
+// themselves; commands take the braced arguments after them, and math in
+// dollar signs is parsed as math.
+func (p *Parser) parseTextInMath(text string, tokenPos lexer.Position) []Node {
+	var nodes []Node
+	var run strings.Builder
+
+	flush := func() {
+		if run.Len() > 0 {
+			nodes = append(nodes, &TextNode{Value: run.String(), Position: tokenPos})
+			run.Reset()
+		}
+	}
+
+	for pos := 0; pos < len(text); {
+		switch {
+		case text[pos] == '\\' && pos+1 < len(text) && strings.ContainsRune("{}%$&#_ ", rune(text[pos+1])):
+			run.WriteByte(text[pos+1])
+			pos += 2
+
+		case text[pos] == '\\' && pos+1 < len(text):
+			flush()
+			end := pos + 1
+			for end < len(text) && isAlpha(text[end]) {
+				end++
+			}
+			if end == pos+1 {
+				end++ // A control symbol such as \,
+			} else {
+				// Spaces after a control word end it
+				for end < len(text) && text[end] == ' ' {
+					end++
+				}
+			}
+			cmd := &Command{Name: strings.TrimRight(text[pos+1:end], " "), Position: tokenPos}
+			pos = end
+			for pos < len(text) && text[pos] == '{' {
+				argEnd := findClosingBrace(text, pos)
+				cmd.Args = append(cmd.Args, &Group{Nodes: p.parseTextInMath(text[pos+1:argEnd], tokenPos), Position: tokenPos})
+				pos = min(argEnd+1, len(text))
+			}
+			nodes = append(nodes, cmd)
+
+		case text[pos] == '$':
+			flush()
+			end := pos + 1
+			for end < len(text) && text[end] != '$' {
+				if text[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(text) {
+				p.addErrorAtPosition(UnmatchedMath, "missing closing delimiter for inline math", Error, tokenPos)
+			}
+			content, _ := p.parseMathExpression(text[pos+1:min(end, len(text))], 0, tokenPos)
+			nodes = append(nodes, &MathNode{Inline: true, Content: content, Position: tokenPos})
+			pos = min(end+1, len(text))
+
+		case text[pos] == '{':
+			flush()
+			end := findClosingBrace(text, pos)
+			nodes = append(nodes, &Group{Nodes: p.parseTextInMath(text[pos+1:end], tokenPos), Position: tokenPos})
+			pos = min(end+1, len(text))
+
+		default:
+			run.WriteByte(text[pos])
+			pos++
+		}
+	}
+	flush()
+
+	return nodes
+}
+
+// findClosingBrace returns where the group opened by the brace at pos is
+// closed, or the end of the text if it is not
+func findClosingBrace(text string, pos int) int {
+	depth := 0
+	for ; pos < len(text); pos++ {
+		switch text[pos] {
+		case '\\':
+			pos++ // Escaped braces such as \{ are not counted
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return pos
+			}
+		}
+	}
+	return len(text)
+}
+
+// readOptionalArgument reads an optional argument in brackets after any
+// spaces, returning its content and where it ends
+func readOptionalArgument(text string, pos int) (string, int, bool) {
+	start := pos
+	for start < len(text) && unicode.IsSpace(rune(text[start])) {
+		start++
+	}
+	if start >= len(text) || text[start] != '[' {
+		return "", pos, false
+	}
+	depth := 0
+	for end := start + 1; end < len(text); end++ {
+		switch text[end] {
+		case '\\':
+			end++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ']':
+			if depth == 0 {
+				return text[start+1 : end], end + 1, true
+			}
+		}
+	}
+	return "", pos, false
+}
+
 // parseBracedMathExpression handles expressions inside {}.
 // It recursively calls the main math parser on the content.
 func (p *Parser) parseBracedMathExpression(text string, startPos int, tokenPos lexer.Position) (Node, int) {
@@ -156,6 +497,8 @@ func (p *Parser) parseBracedMathExpression(text string, startPos int, tokenPos l
 	// Find the matching closing brace to support nested braces
 	for pos < len(text) && braceCount > 0 {
 		switch text[pos] {
+		case '\\':
+			pos++ // Escaped braces such as \{ are not counted
 		case '{':
 			braceCount++
 		case '}':
@@ -189,6 +532,190 @@ func (p *Parser) parseBracedMathExpression(text string, startPos int, tokenPos l
 	return &Group{Nodes: parsedContent, Position: tokenPos}, pos
 }
 
+// parseDelimiter reads the delimiter after \left, \right, \middle or \big,
+// which is a character such as ( or a command such as \langle. A period
+// stands for no delimiter, which is returned as an empty string.
+func parseDelimiter(text string, pos int) (string, int) {
+	for pos < len(text) && unicode.IsSpace(rune(text[pos])) {
+		pos++
+	}
+	if pos >= len(text) {
+		return "", pos
+	}
+
+	if text[pos] == '\\' {
+		end := pos + 1
+		for end < len(text) && isAlpha(text[end]) {
+			end++
+		}
+		if end == pos+1 && end < len(text) {
+			end++
+		}
+		name := text[pos+1 : end]
+		if name == "{" || name == "}" {
+			return name, end
+		}
+		symbol, _ := symbols.ConvertMathSymbol(name)
+		return symbol, end
+	}
+
+	_, size := utf8.DecodeRuneInString(text[pos:])
+	switch delimiter := text[pos : pos+size]; delimiter {
+	case ".":
+		return "", pos + size
+	case "<":
+		return "⟨", pos + size
+	case ">":
+		return "⟩", pos + size
+	default:
+		return delimiter, pos + size
+	}
+}
+
+// findRight returns where the \right that closes a \left starts, skipping
+// \left and \right pairs nested in between, or -1 if there is none
+func findRight(text string, pos int) int {
+	depth := 1
+	for pos < len(text) {
+		if text[pos] != '\\' {
+			pos++
+			continue
+		}
+		start := pos
+		pos++
+		for pos < len(text) && isAlpha(text[pos]) {
+			pos++
+		}
+		switch text[start+1 : pos] {
+		case "":
+			pos++ // A control symbol such as \{
+		case "left":
+			depth++
+		case "right":
+			depth--
+			if depth == 0 {
+				return start
+			}
+		}
+	}
+	return -1
+}
+
+// readEnvironmentName reads the braced name after \begin or \end,
+// returning an empty name if there is none
+func readEnvironmentName(text string, pos int) (string, int) {
+	start := pos
+	for start < len(text) && text[start] == ' ' {
+		start++
+	}
+	if start >= len(text) || text[start] != '{' {
+		return "", pos
+	}
+	end := strings.IndexByte(text[start:], '}')
+	if end < 0 {
+		return "", pos
+	}
+	return strings.TrimSpace(text[start+1 : start+end]), start + end + 1
+}
+
+// findEnd returns where the \end that closes an environment starts and
+// ends, skipping environments of the same name nested in it, or -1 if there
+// is none
+func findEnd(text string, pos int, name string) (int, int) {
+	depth := 1
+	for pos < len(text) {
+		if text[pos] != '\\' {
+			pos++
+			continue
+		}
+		start := pos
+		pos++
+		for pos < len(text) && isAlpha(text[pos]) {
+			pos++
+		}
+		switch text[start+1 : pos] {
+		case "":
+			pos++ // A control symbol such as \{
+		case "begin", "end":
+			env, end := readEnvironmentName(text, pos)
+			if env != name {
+				continue
+			}
+			if text[start+1:pos] == "begin" {
+				depth++
+			} else if depth--; depth == 0 {
+				return start, end
+			}
+			pos = end
+		}
+	}
+	return -1, len(text)
+}
+
+// parseArrayRows splits the body of an environment such as pmatrix into
+// rows at \\ and cells at &, leaving those inside braces and nested
+// environments alone, and parses each cell
+func (p *Parser) parseArrayRows(body string, tokenPos lexer.Position) [][]Node {
+	var rows [][]Node
+	var row []Node
+	cellStart, depth := 0, 0
+	addCell := func(end int) {
+		cell, _ := p.parseMathExpression(body[cellStart:end], 0, tokenPos)
+		row = append(row, &Group{Nodes: cell, Position: tokenPos})
+	}
+
+	pos := 0
+	for pos < len(body) {
+		switch ch := body[pos]; {
+		case ch == '{':
+			depth++
+			pos++
+		case ch == '}':
+			depth--
+			pos++
+		case ch == '&' && depth == 0:
+			addCell(pos)
+			pos++
+			cellStart = pos
+		case ch == '\\' && pos+1 < len(body) && body[pos+1] == '\\' && depth == 0:
+			// A row ends at \\, which may give extra space in brackets
+			addCell(pos)
+			rows = append(rows, row)
+			row = nil
+			pos += 2
+			if rest := strings.TrimLeft(body[pos:], " \t\n"); strings.HasPrefix(rest, "[") {
+				if end := strings.IndexByte(rest, ']'); end >= 0 {
+					pos = len(body) - len(rest) + end + 1
+				}
+			}
+			cellStart = pos
+		case ch == '\\':
+			start := pos
+			pos++
+			for pos < len(body) && isAlpha(body[pos]) {
+				pos++
+			}
+			switch body[start+1 : pos] {
+			case "":
+				pos++ // Escaped characters such as \& are not separators
+			case "begin":
+				depth++
+			case "end":
+				depth--
+			}
+		default:
+			pos++
+		}
+	}
+
+	// A \\ after the last row does not start another
+	if cellStart < len(body) && strings.TrimSpace(body[cellStart:]) != "" || len(row) > 0 {
+		addCell(len(body))
+		rows = append(rows, row)
+	}
+	return rows
+}
+
 // isMathSpecialChar checks for characters that have special meaning in our math parser.
 func isMathSpecialChar(char byte) bool {
 	return char == '^' || char == '_' || char == '\\' || char == '{' || char == '}'
@@ -7,6 +7,8 @@ func (p *Parser) parseNode() Node {
 	switch p.curToken.Type {
 	case lexer.TokenCommand:
 		return p.parseCommand()
+	case lexer.TokenControlSymbol:
+		return p.parseControlSymbol()
 	case lexer.TokenBeginEnv:
 		return p.parseEnvironment()
 	case lexer.TokenText:
@@ -15,14 +17,15 @@ func (p *Parser) parseNode() Node {
 		return p.parseMath()
 	case lexer.TokenComment:
 		return p.parseComment()
+	case lexer.TokenVerbatim:
+		return p.parseVerbatim()
 	case lexer.TokenRBrace:
 		// Unmatched closing brace at document level - this is a warning since we can recover
 		p.addWarning(UnexpectedToken, "unexpected '}' - no matching '{'")
 		return nil
 	case lexer.TokenLBrace:
-		// Unmatched opening brace at document level - this is an error since it affects parsing
-		p.addError(UnexpectedToken, "unexpected '{' - not part of a command", Error)
-		return nil
+		// A bare group scopes declarations such as \em and \color
+		return p.parseGroup()
 	default:
 		// For other unexpected tokens
 		return nil
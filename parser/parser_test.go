@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/rickykimani/gotex/lexer"
@@ -155,3 +156,315 @@ func printIndent(level int) {
 		fmt.Print("  ")
 	}
 }
+
+func TestMathOperatorNames(t *testing.T) {
+	math := ParseMath(`\lim_{n} \operatorname*{arg\,max} x`, false, lexer.Position{})
+	printNode(math, 0)
+
+	if len(math.Content) != 3 {
+		t.Fatalf("Expected 3 math nodes, got %d", len(math.Content))
+	}
+
+	sub, ok := math.Content[0].(*MathSubscript)
+	if !ok {
+		t.Fatalf("Expected a subscript, got %T", math.Content[0])
+	}
+	if base, ok := sub.Base.(*Command); !ok || base.Name != "lim" {
+		t.Errorf("Expected \\lim as the subscript base, got %#v", sub.Base)
+	}
+
+	op, ok := math.Content[1].(*Command)
+	if !ok || op.Name != "operatorname*" || len(op.Args) != 1 {
+		t.Fatalf("Expected \\operatorname* with one argument, got %#v", math.Content[1])
+	}
+	if group, ok := op.Args[0].(*Group); !ok || len(group.Nodes) != 3 {
+		t.Errorf("Expected the operator name to be arg, \\, and max, got %#v", op.Args[0])
+	}
+
+	if text, ok := math.Content[2].(*TextNode); !ok || text.Value != " x" {
+		t.Errorf("Expected the text \" x\", got %#v", math.Content[2])
+	}
+}
+
+func TestMathScriptBase(t *testing.T) {
+	math := ParseMath(`E = mc^2`, false, lexer.Position{})
+	printNode(math, 0)
+
+	if len(math.Content) != 2 {
+		t.Fatalf("Expected 2 math nodes, got %d", len(math.Content))
+	}
+	if text, ok := math.Content[0].(*TextNode); !ok || text.Value != "E = m" {
+		t.Errorf("Expected the text \"E = m\", got %#v", math.Content[0])
+	}
+	sup, ok := math.Content[1].(*MathSuperscript)
+	if !ok {
+		t.Fatalf("Expected a superscript, got %T", math.Content[1])
+	}
+	if base, ok := sup.Base.(*TextNode); !ok || base.Value != "c" {
+		t.Errorf("Expected c as the superscript base, got %#v", sup.Base)
+	}
+}
+
+func TestMathLimits(t *testing.T) {
+	math := ParseMath(`\int\limits_0^1`, false, lexer.Position{})
+	printNode(math, 0)
+
+	if len(math.Content) != 1 {
+		t.Fatalf("Expected 1 math node, got %d", len(math.Content))
+	}
+	sup, ok := math.Content[0].(*MathSuperscript)
+	if !ok {
+		t.Fatalf("Expected a superscript, got %T", math.Content[0])
+	}
+	sub, ok := sup.Base.(*MathSubscript)
+	if !ok {
+		t.Fatalf("Expected a subscript as the superscript base, got %T", sup.Base)
+	}
+	limits, ok := sub.Base.(*Command)
+	if !ok || limits.Name != "limits" || len(limits.Args) != 1 {
+		t.Fatalf("Expected \\limits wrapping the operator, got %#v", sub.Base)
+	}
+	if op, ok := limits.Args[0].(*MathSymbol); !ok || op.Symbol != "∫" {
+		t.Errorf("Expected \\int under \\limits, got %#v", limits.Args[0])
+	}
+}
+
+func TestMathDelimiters(t *testing.T) {
+	math := ParseMath(`\left\{ x \middle| \left( y \right) \right. \Bigl\langle`, false, lexer.Position{})
+	printNode(math, 0)
+
+	if len(math.Content) != 2 {
+		t.Fatalf("Expected 2 math nodes, got %d", len(math.Content))
+	}
+	delimited, ok := math.Content[0].(*MathDelimited)
+	if !ok {
+		t.Fatalf("Expected a \\left ... \\right pair, got %T", math.Content[0])
+	}
+	if delimited.Left != "{" || delimited.Right != "" {
+		t.Errorf("Expected the delimiters { and none, got %q and %q", delimited.Left, delimited.Right)
+	}
+	if len(delimited.Content) != 3 {
+		t.Fatalf("Expected 3 nodes between \\left and \\right, got %d", len(delimited.Content))
+	}
+	if middle, ok := delimited.Content[1].(*Command); !ok || middle.Name != "middle" {
+		t.Errorf("Expected \\middle, got %#v", delimited.Content[1])
+	}
+	if inner, ok := delimited.Content[2].(*MathDelimited); !ok || inner.Left != "(" || inner.Right != ")" {
+		t.Errorf("Expected a nested pair of parentheses, got %#v", delimited.Content[2])
+	}
+
+	big, ok := math.Content[1].(*Command)
+	if !ok || big.Name != "Bigl" || len(big.Args) != 1 {
+		t.Fatalf("Expected \\Bigl with its delimiter, got %#v", math.Content[1])
+	}
+	if symbol, ok := big.Args[0].(*MathSymbol); !ok || symbol.Symbol != "⟨" {
+		t.Errorf("Expected the delimiter ⟨, got %#v", big.Args[0])
+	}
+}
+
+func TestMathArrays(t *testing.T) {
+	math := ParseMath(`\begin{pmatrix} a & {b & c} \\[2pt] \begin{matrix} x \\ y \end{matrix} & \& \\ \end{pmatrix}`, false, lexer.Position{})
+	printNode(math, 0)
+
+	if len(math.Content) != 1 {
+		t.Fatalf("Expected 1 math node, got %d", len(math.Content))
+	}
+	array, ok := math.Content[0].(*MathArray)
+	if !ok || array.Name != "pmatrix" {
+		t.Fatalf("Expected a pmatrix, got %#v", math.Content[0])
+	}
+	if len(array.Rows) != 2 {
+		t.Fatalf("Expected 2 rows, the \\\\ at the end starting none, got %d", len(array.Rows))
+	}
+	for i, row := range array.Rows {
+		if len(row) != 2 {
+			t.Errorf("Row %d: expected 2 cells, got %d", i, len(row))
+		}
+	}
+
+	nested, ok := array.Rows[1][0].(*Group)
+	if !ok || len(nested.Nodes) != 1 {
+		t.Fatalf("Expected the nested matrix as the only node of its cell, got %#v", array.Rows[1][0])
+	}
+	if inner, ok := nested.Nodes[0].(*MathArray); !ok || len(inner.Rows) != 2 {
+		t.Errorf("Expected a nested matrix of 2 rows, got %#v", nested.Nodes[0])
+	}
+}
+
+func TestMathDisplayLines(t *testing.T) {
+	input := `\begin{align*}
+a &= {b \\ c} \tag*{A} \\
+\end{align*}`
+
+	doc := New(lexer.NewLexer(input)).ParseDocument()
+	printDocument(doc, 0)
+
+	if len(doc.Body) != 1 {
+		t.Fatalf("Expected 1 node, got %d", len(doc.Body))
+	}
+	env, ok := doc.Body[0].(*Environment)
+	if !ok || env.Name != "align*" {
+		t.Fatalf("Expected an align* environment, got %#v", doc.Body[0])
+	}
+
+	var names []string
+	for _, node := range env.Body {
+		if cmd, ok := node.(*Command); ok {
+			names = append(names, cmd.Name)
+		}
+	}
+	if fmt.Sprint(names) != `[tag* \]` {
+		t.Errorf("Expected \\tag* and one \\\\ outside the braces, got %v", names)
+	}
+}
+
+func TestMathAlphabets(t *testing.T) {
+	math := ParseMath(`\mathbb{R}^n \mathbf x \boldsymbol\alpha`, false, lexer.Position{})
+	printNode(math, 0)
+
+	if len(math.Content) != 3 {
+		t.Fatalf("Expected 3 math nodes, got %d", len(math.Content))
+	}
+
+	script, ok := math.Content[0].(*MathSuperscript)
+	if !ok {
+		t.Fatalf("Expected a superscript, got %#v", math.Content[0])
+	}
+	if cmd, ok := script.Base.(*Command); !ok || cmd.Name != "mathbb" || len(cmd.Args) != 1 {
+		t.Errorf("Expected \\mathbb{R} as the base, got %#v", script.Base)
+	}
+
+	if cmd, ok := math.Content[1].(*Command); !ok || len(cmd.Args) != 1 {
+		t.Errorf("Expected \\mathbf with its argument, got %#v", math.Content[1])
+	} else if arg, ok := cmd.Args[0].(*TextNode); !ok || arg.Value != "x" {
+		t.Errorf("Expected x as the argument of \\mathbf, got %#v", cmd.Args[0])
+	}
+
+	if cmd, ok := math.Content[2].(*Command); !ok || len(cmd.Args) != 1 {
+		t.Errorf("Expected \\boldsymbol with its argument, got %#v", math.Content[2])
+	} else if arg, ok := cmd.Args[0].(*MathSymbol); !ok || arg.Symbol != "α" {
+		t.Errorf("Expected α as the argument of \\boldsymbol, got %#v", cmd.Args[0])
+	}
+}
+
+func TestMathText(t *testing.T) {
+	math := ParseMath(`x \text{ if } y \textbf{50\%}`, false, lexer.Position{})
+	printNode(math, 0)
+
+	if len(math.Content) != 4 {
+		t.Fatalf("Expected 4 math nodes, got %d", len(math.Content))
+	}
+	if text, ok := math.Content[1].(*MathText); !ok || text.Command != "text" || onlyText(text.Content) != " if " {
+		t.Errorf("Expected the text \" if \" with its spaces, got %#v", math.Content[1])
+	}
+	if text, ok := math.Content[3].(*MathText); !ok || text.Command != "textbf" || onlyText(text.Content) != "50%" {
+		t.Errorf("Expected the bold text \"50%%\", got %#v", math.Content[3])
+	}
+}
+
+func TestMathTextContent(t *testing.T) {
+	math := ParseMath(`\text{if $y>0$ ok} \text{a \ldots{} b -- \textbf{c}}`, false, lexer.Position{})
+	printNode(math, 0)
+
+	if len(math.Content) != 2 {
+		t.Fatalf("Expected 2 math nodes, got %d", len(math.Content))
+	}
+
+	text, ok := math.Content[0].(*MathText)
+	if !ok || len(text.Content) != 3 {
+		t.Fatalf("Expected text, math and text, got %#v", math.Content[0])
+	}
+	if onlyText(text.Content[:1]) != "if " || onlyText(text.Content[2:]) != " ok" {
+		t.Errorf("Expected \"if \" and \" ok\" with their spaces, got %#v and %#v", text.Content[0], text.Content[2])
+	}
+	if nested, ok := text.Content[1].(*MathNode); !ok || !nested.Inline || len(nested.Content) == 0 {
+		t.Errorf("Expected the inline math y>0, got %#v", text.Content[1])
+	}
+
+	text, ok = math.Content[1].(*MathText)
+	if !ok || len(text.Content) != 4 {
+		t.Fatalf("Expected text, \\ldots, text and \\textbf, got %#v", math.Content[1])
+	}
+	if cmd, ok := text.Content[1].(*Command); !ok || cmd.Name != "ldots" {
+		t.Errorf("Expected \\ldots, got %#v", text.Content[1])
+	}
+	if onlyText(text.Content[2:3]) != " b -- " {
+		t.Errorf("Expected the text \" b -- \", got %#v", text.Content[2])
+	}
+	if cmd, ok := text.Content[3].(*Command); !ok || cmd.Name != "textbf" || len(cmd.Args) != 1 {
+		t.Errorf("Expected \\textbf with its argument, got %#v", text.Content[3])
+	} else if group, ok := cmd.Args[0].(*Group); !ok || onlyText(group.Nodes) != "c" {
+		t.Errorf("Expected c as the argument of \\textbf, got %#v", cmd.Args[0])
+	}
+}
+
+func TestMathStrayBrace(t *testing.T) {
+	math := ParseMath(`a}b`, true, lexer.Position{})
+	if len(math.Content) != 2 {
+		t.Errorf("Expected a and b without the brace, got %d nodes", len(math.Content))
+	}
+}
+
+// onlyText returns the text of nodes that are all text, or "" otherwise
+func onlyText(nodes []Node) string {
+	var b strings.Builder
+	for _, node := range nodes {
+		text, ok := node.(*TextNode)
+		if !ok {
+			return ""
+		}
+		b.WriteString(text.Value)
+	}
+	return b.String()
+}
+
+func TestMathAccents(t *testing.T) {
+	math := ParseMath(`\hat x \widetilde{ab} \vec\alpha \underbrace{x+y}_{n} \overset{\text{def}}{=} \xrightarrow[g]{f}`, false, lexer.Position{})
+	printNode(math, 0)
+
+	if len(math.Content) != 6 {
+		t.Fatalf("Expected 6 math nodes, got %d", len(math.Content))
+	}
+
+	accents := []struct {
+		command, accent string
+		wide            bool
+	}{
+		{"hat", "ˆ", false},
+		{"widetilde", "˜", true},
+		{"vec", "→", false},
+	}
+	for i, expected := range accents {
+		accent, ok := math.Content[i].(*MathAccent)
+		if !ok || accent.Command != expected.command || accent.Accent != expected.accent || accent.Wide != expected.wide {
+			t.Errorf("Node %d: expected \\%s, got %#v", i, expected.command, math.Content[i])
+			continue
+		}
+		if accent.Base == nil {
+			t.Errorf("Node %d: expected a base", i)
+		}
+	}
+	if base, ok := math.Content[2].(*MathAccent).Base.(*MathSymbol); !ok || base.Symbol != "α" {
+		t.Errorf("Expected \\vec over α, got %#v", math.Content[2].(*MathAccent).Base)
+	}
+
+	script, ok := math.Content[3].(*MathSubscript)
+	if !ok {
+		t.Fatalf("Expected the label of \\underbrace as a subscript, got %#v", math.Content[3])
+	}
+	if brace, ok := script.Base.(*MathOverUnder); !ok || brace.Command != "underbrace" || brace.Base == nil {
+		t.Errorf("Expected an underbrace as the base, got %#v", script.Base)
+	}
+
+	overset, ok := math.Content[4].(*MathOverUnder)
+	if !ok || overset.Over == nil || overset.Under != nil {
+		t.Errorf("Expected \\overset with only something over its base, got %#v", math.Content[4])
+	} else if base, ok := overset.Base.(*TextNode); !ok || base.Value != "=" {
+		t.Errorf("Expected = as the base of \\overset, got %#v", overset.Base)
+	}
+
+	arrow, ok := math.Content[5].(*MathOverUnder)
+	if !ok || arrow.Base != nil || arrow.Over == nil || arrow.Under == nil {
+		t.Errorf("Expected an arrow with f over and g under it, got %#v", math.Content[5])
+	}
+}
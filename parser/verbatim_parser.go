@@ -0,0 +1,8 @@
+package parser
+
+func (p *Parser) parseVerbatim() *VerbatimNode {
+	return &VerbatimNode{
+		Value:    p.curToken.Value,
+		Position: p.curToken.Pos,
+	}
+}
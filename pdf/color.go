@@ -0,0 +1,54 @@
+package pdf
+
+// Color is an RGB color with 8-bit channels
+type Color struct {
+	R, G, B uint8
+}
+
+// Black is the default drawing color
+var Black = Color{0, 0, 0}
+
+// SetColor sets the color used by subsequent text and line operations
+func (g *Generator) SetColor(c Color) {
+	if g.recording {
+		g.recordedColor = c
+	}
+	g.draw(func(float64, float64) {
+		g.applyColor(c)
+	})
+}
+
+// Color returns the color used for text and lines. While recording it is
+// the color recorded operations will be drawn in.
+func (g *Generator) Color() Color {
+	if g.recording {
+		return g.recordedColor
+	}
+	return g.color
+}
+
+func (g *Generator) applyColor(c Color) {
+	g.color = c
+	g.pdf.SetTextColor(c.R, c.G, c.B)
+	g.pdf.SetStrokeColor(c.R, c.G, c.B)
+}
+
+// AddFilledRect fills a rectangle whose lower-left corner is at (x, y)
+func (g *Generator) AddFilledRect(x, y, width, height float64, fill Color) {
+	g.draw(func(dx, dy float64) {
+		g.pdf.SetFillColor(fill.R, fill.G, fill.B)
+		g.pdf.RectFromLowerLeftWithStyle(x+dx, g.PageHeight-(y+dy), width, height, "F")
+	})
+}
+
+// AddFramedRect fills a rectangle whose lower-left corner is at (x, y) and
+// strokes its border
+func (g *Generator) AddFramedRect(x, y, width, height float64, frame, fill Color) {
+	g.draw(func(dx, dy float64) {
+		g.pdf.SetFillColor(fill.R, fill.G, fill.B)
+		g.pdf.SetStrokeColor(frame.R, frame.G, frame.B)
+		g.pdf.SetLineWidth(0.4)
+		g.pdf.RectFromLowerLeftWithStyle(x+dx, g.PageHeight-(y+dy), width, height, "FD")
+		g.pdf.SetStrokeColor(g.color.R, g.color.G, g.color.B)
+	})
+}
@@ -0,0 +1,89 @@
+package pdf_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rickykimani/gotex/lexer"
+	"github.com/rickykimani/gotex/macro"
+	"github.com/rickykimani/gotex/parser"
+	"github.com/rickykimani/gotex/pdf"
+	"github.com/rickykimani/gotex/processor"
+	"github.com/rickykimani/gotex/ttf"
+)
+
+// deterministicInput touches every font, links, bookmarks, placeholders,
+// colors, columns and the metadata, which are the parts of the output that have
+// depended on map order or the clock
+const deterministicInput = `\documentclass{article}
+\usepackage[colorlinks]{hyperref}
+\hypersetup{pdfkeywords={one, two}}
+\title{Reproducible}
+\author{GoTeX}
+\begin{document}
+\maketitle
+\tableofcontents
+\section{Text}
+\label{sec:text}
+Plain, \textbf{bold}, \textit{italic}, \textbf{\textit{both}} and \verb|mono|.
+{\color{blue} Blue text} with a link to \url{https://example.com} and Section \ref{sec:math}.
+\section{Math}
+\label{sec:math}
+Inline $x^2 + y_1 = \frac{a}{b}$ and \today.
+\begin{lstlisting}[language=go, caption=Example]
+func main() {}
+\end{lstlisting}
+\begin{multicols}{2}
+Left column text.
+\columnbreak
+Right column text.
+\end{multicols}
+\end{document}`
+
+func buildPDF(t *testing.T, built time.Time) []byte {
+	t.Helper()
+
+	doc, _ := parser.NewParser(lexer.NewLexer(deterministicInput).Tokenize()).Parse()
+	store := macro.NewMacroStore(nil)
+	store.AddBuiltins()
+	expanded := macro.NewExpander(store).ExpandDocument(doc)
+
+	generator, err := pdf.NewGenerator(ttf.FS)
+	if err != nil {
+		t.Fatalf("creating generator: %v", err)
+	}
+
+	dp := processor.NewDocumentProcessor(generator)
+	dp.SetBuildTime(built)
+	dp.ProcessDocument(expanded.Body)
+
+	data, err := generator.Bytes()
+	if err != nil {
+		t.Fatalf("writing PDF: %v", err)
+	}
+	return data
+}
+
+func TestDeterministicOutput(t *testing.T) {
+	built := time.Unix(1700000000, 0).UTC()
+
+	// Map iteration order changes between iterations, so several builds
+	// are needed to catch output that depends on it
+	first := buildPDF(t, built)
+	for i := 1; i < 5; i++ {
+		if next := buildPDF(t, built); !bytes.Equal(first, next) {
+			t.Fatalf("build %d differs from the first build (%d vs %d bytes)", i+1, len(next), len(first))
+		}
+	}
+	fmt.Printf("%d identical builds of %d bytes\n", 5, len(first))
+
+	if !bytes.Contains(first, []byte("/CreationDate (D:20231114221320Z)")) {
+		t.Errorf("expected the creation date to come from the build time")
+	}
+
+	if other := buildPDF(t, built.Add(time.Hour)); bytes.Equal(first, other) {
+		t.Errorf("expected a different build time to change the output")
+	}
+}
@@ -2,6 +2,8 @@ package pdf
 
 import (
 	"fmt"
+	"io/fs"
+	"os"
 
 	"github.com/rickykimani/gotex/fonts"
 	"github.com/signintech/gopdf"
@@ -23,10 +25,28 @@ type Generator struct {
 	// State tracking
 	CurrentPage int
 	pageCount   int
+	color       Color
+
+	// Drawing recorded for later placement, and the color set last while
+	// recording, which is not the page's color until it is replayed
+	recording     bool
+	operations    []operation
+	drawn         []bool // Whether each operation draws on the page
+	recordedColor Color
+
+	// First error from a replayed operation, returned by Bytes
+	err error
+
+	// Bookmark tree and the open entries new bookmarks may nest under
+	outlineRoots  []*gopdf.OutlineNode
+	outlineStack  []*gopdf.OutlineNode
+	outlineLevels []int
+
+	metadata Metadata
 }
 
-// NewGenerator creates a new PDF generator with gopdf backend
-func NewGenerator(ttfDir string) (*Generator, error) {
+// NewGenerator creates a new PDF generator with gopdf backend, loading fonts from fontFS
+func NewGenerator(fontFS fs.FS) (*Generator, error) {
 	pdf := &gopdf.GoPdf{}
 
 	// Configure page settings
@@ -38,7 +58,7 @@ func NewGenerator(ttfDir string) (*Generator, error) {
 	pdf.Start(pageConfig)
 
 	// Initialize font mapper
-	fontMapper := fonts.NewFontMapper(pdf, ttfDir)
+	fontMapper := fonts.NewFontMapper(pdf, fontFS)
 
 	//TODO: Automate inch to pt with gopdf tools
 
@@ -68,6 +88,9 @@ func (g *Generator) NewPage() {
 	g.pdf.AddPage()
 	g.CurrentPage++
 	g.pageCount++
+
+	// Carry the current color over to the new page
+	g.applyColor(g.color)
 }
 
 // AddText adds text at the specified position with the given style
@@ -76,18 +99,20 @@ func (g *Generator) AddText(text string, x, y, fontSize float64, style string) {
 		return
 	}
 
-	// Set font for the style
-	if err := g.fontMapper.SetFont(style, fontSize); err != nil {
-		// Fallback to regular font if style not available
-		g.fontMapper.SetFont("normal", fontSize)
-	}
+	g.draw(func(dx, dy float64) {
+		// Set font for the style
+		if err := g.fontMapper.SetFont(style, fontSize); err != nil {
+			// Fallback to regular font if style not available
+			g.fontMapper.SetFont("normal", fontSize)
+		}
 
-	// Convert our coordinate system (top-left origin) to PDF coordinate system (bottom-left origin)
-	pdfY := g.PageHeight - y
+		// Convert our coordinate system (top-left origin) to PDF coordinate system (bottom-left origin)
+		pdfY := g.PageHeight - (y + dy)
 
-	g.pdf.SetX(x)
-	g.pdf.SetY(pdfY)
-	g.pdf.Text(text)
+		g.pdf.SetX(x + dx)
+		g.pdf.SetY(pdfY)
+		g.pdf.Text(text)
+	})
 }
 
 // AddTextWithAlignment adds text with specified alignment
@@ -96,30 +121,34 @@ func (g *Generator) AddTextWithAlignment(text string, x, y, fontSize float64, st
 		return
 	}
 
-	// Set font for the style
-	if err := g.fontMapper.SetFont(style, fontSize); err != nil {
-		g.fontMapper.SetFont("normal", fontSize)
-	}
-
-	// Calculate alignment offset
-	textWidth, _ := g.pdf.MeasureTextWidth(text)
-
-	var alignedX float64
-	switch alignment {
-	case "center":
-		alignedX = (g.PageWidth - textWidth) / 2
-	case "right":
-		alignedX = g.PageWidth - g.MarginRight - textWidth
-	default: // left
-		alignedX = x
-	}
-
-	// Convert coordinate system
-	pdfY := g.PageHeight - y
-
-	g.pdf.SetX(alignedX)
-	g.pdf.SetY(pdfY)
-	g.pdf.Text(text)
+	// Align within the text width in effect now, not when replayed
+	left, right := g.MarginLeft, g.PageWidth-g.MarginRight
+	g.draw(func(dx, dy float64) {
+		// Set font for the style
+		if err := g.fontMapper.SetFont(style, fontSize); err != nil {
+			g.fontMapper.SetFont("normal", fontSize)
+		}
+
+		// Calculate alignment offset
+		textWidth, _ := g.pdf.MeasureTextWidth(text)
+
+		var alignedX float64
+		switch alignment {
+		case "center":
+			alignedX = (left + right - textWidth) / 2
+		case "right":
+			alignedX = right - textWidth
+		default: // left
+			alignedX = x
+		}
+
+		// Convert coordinate system
+		pdfY := g.PageHeight - (y + dy)
+
+		g.pdf.SetX(alignedX + dx)
+		g.pdf.SetY(pdfY)
+		g.pdf.Text(text)
+	})
 }
 
 // AddTitle adds a title with larger font size
@@ -142,14 +171,15 @@ func (g *Generator) AddSubsection(text string, x, y, baseFontSize float64) {
 
 // AddLine adds a line from (x1,y1) to (x2,y2)
 func (g *Generator) AddLine(x1, y1, x2, y2 float64) {
-	// Convert coordinate system
-	pdfY1 := g.PageHeight - y1
-	pdfY2 := g.PageHeight - y2
-
-	// Set stroke properties to make the line visible
-	g.pdf.SetStrokeColor(0, 0, 0) // Black
-	g.pdf.SetLineWidth(0.5)       // Thin line
-	g.pdf.Line(x1, pdfY1, x2, pdfY2)
+	g.draw(func(dx, dy float64) {
+		// Convert coordinate system
+		pdfY1 := g.PageHeight - (y1 + dy)
+		pdfY2 := g.PageHeight - (y2 + dy)
+
+		// Lines are drawn in the current color
+		g.pdf.SetLineWidth(0.5) // Thin line
+		g.pdf.Line(x1+dx, pdfY1, x2+dx, pdfY2)
+	})
 }
 
 // Legacy compatibility methods that map old font names to new styles
@@ -190,6 +220,15 @@ func (g *Generator) GetTextWidth(text string, fontSize float64, style string) fl
 	return width
 }
 
+// HasGlyph reports whether the font of a style has a glyph for a character
+func (g *Generator) HasGlyph(r rune, style string) bool {
+	if err := g.fontMapper.SetFont(style, 10); err != nil {
+		return false
+	}
+	ok, err := g.pdf.IsCurrFontContainGlyph(r)
+	return err == nil && ok
+}
+
 // GetPageCount returns the current number of pages
 func (g *Generator) GetPageCount() int {
 	return g.pageCount
@@ -197,5 +236,25 @@ func (g *Generator) GetPageCount() int {
 
 // GeneratePDF writes the PDF to a file
 func (g *Generator) GeneratePDF(filename string) error {
-	return g.pdf.WritePdf(filename)
+	data, err := g.Bytes()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0o644)
+}
+
+// Bytes returns the finished PDF. The output depends only on what was drawn
+// and the metadata, so identical input gives byte-identical files.
+func (g *Generator) Bytes() ([]byte, error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+	data, err := g.pdf.GetBytesPdfReturnErr()
+	if err != nil {
+		return nil, err
+	}
+	if data, err = g.appendOutlines(data); err != nil {
+		return nil, err
+	}
+	return g.appendMetadata(data)
 }
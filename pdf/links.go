@@ -0,0 +1,57 @@
+package pdf
+
+import "github.com/signintech/gopdf"
+
+// AddExternalLink makes the rectangle with lower-left corner (x, y) a link to url
+func (g *Generator) AddExternalLink(url string, x, y, width, height float64) {
+	g.draw(func(dx, dy float64) {
+		g.pdf.AddExternalLink(url, x+dx, g.PageHeight-(y+dy)-height, width, height)
+	})
+}
+
+// AddInternalLink makes the rectangle with lower-left corner (x, y) a link to
+// the named anchor. The anchor may be set later in the document.
+func (g *Generator) AddInternalLink(anchor string, x, y, width, height float64) {
+	g.draw(func(dx, dy float64) {
+		g.pdf.AddInternalLink(anchor, x+dx, g.PageHeight-(y+dy)-height, width, height)
+	})
+}
+
+// SetAnchor names the position y on the current page as a link target
+func (g *Generator) SetAnchor(name string, y float64) {
+	g.note(func(_, dy float64) {
+		g.pdf.SetY(g.PageHeight - (y + dy))
+		g.pdf.SetAnchor(name)
+	})
+}
+
+// AddPlaceholder reserves width points at (x, y) for text that is only known
+// later, such as a page number in a table of contents. All placeholders
+// sharing a name are filled by FillPlaceholder. While recording, errors are
+// reported by Bytes instead.
+func (g *Generator) AddPlaceholder(name string, x, y, width, fontSize float64, style string) error {
+	var err error
+	recorded := g.recording
+	g.draw(func(dx, dy float64) {
+		if err := g.fontMapper.SetFont(style, fontSize); err != nil {
+			g.fontMapper.SetFont("normal", fontSize)
+		}
+
+		g.pdf.SetX(x + dx)
+		g.pdf.SetY(g.PageHeight - (y + dy))
+		err = g.pdf.PlaceHolderText(name, width)
+		if err != nil && recorded && g.err == nil {
+			g.err = err
+		}
+	})
+	return err
+}
+
+// FillPlaceholder right-aligns text in the placeholders called name. The
+// style must match the one the placeholders were created with.
+func (g *Generator) FillPlaceholder(name, text string, fontSize float64, style string) error {
+	if err := g.fontMapper.SetFont(style, fontSize); err != nil {
+		g.fontMapper.SetFont("normal", fontSize)
+	}
+	return g.pdf.FillInPlaceHoldText(name, text, gopdf.Right)
+}
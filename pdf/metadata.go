@@ -0,0 +1,220 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Metadata is the document information shown by PDF viewers. It is written
+// both as the document information dictionary and as an XMP stream.
+type Metadata struct {
+	Title    string
+	Author   string
+	Subject  string
+	Keywords []string
+	Creator  string // the program that produced the source, normally GoTeX
+	Producer string
+
+	// Zero dates are left out
+	CreationDate time.Time
+	ModDate      time.Time
+}
+
+// SetMetadata sets the document information written with the PDF
+func (g *Generator) SetMetadata(m Metadata) {
+	g.metadata = m
+}
+
+// Metadata returns the document information written with the PDF
+func (g *Generator) Metadata() Metadata {
+	return g.metadata
+}
+
+var (
+	catalogPattern   = regexp.MustCompile(`(?s)\n1 0 obj\s*<<(.*?)>>\s*endobj`)
+	sizePattern      = regexp.MustCompile(`/Size (\d+)`)
+	startxrefPattern = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+)
+
+// appendMetadata adds the information dictionary and XMP stream to a PDF
+// written by gopdf as an incremental update. gopdf cannot write keywords or
+// XMP and writes a malformed creation date, so the update adds new objects,
+// redefines the catalog to reference the XMP stream and ends with a trailer
+// carrying /Info and a document /ID derived from the content.
+func (g *Generator) appendMetadata(data []byte) ([]byte, error) {
+	catalog := catalogPattern.FindSubmatch(data)
+	sizes := sizePattern.FindAllSubmatch(data, -1)
+	startxref := startxrefPattern.FindSubmatch(data)
+	if catalog == nil || sizes == nil || startxref == nil {
+		return nil, fmt.Errorf("unexpected PDF structure from gopdf")
+	}
+
+	// The new objects follow the highest object number in the trailer
+	infoID, _ := strconv.Atoi(string(sizes[len(sizes)-1][1]))
+	xmpID := infoID + 1
+	m := g.metadata
+	id := documentID(data, m)
+
+	var out bytes.Buffer
+	out.Write(data)
+	offsets := make(map[int]int)
+
+	offsets[1] = out.Len()
+	fmt.Fprintf(&out, "1 0 obj\n<<%s  /Metadata %d 0 R\n>>\nendobj\n", catalog[1], xmpID)
+
+	offsets[infoID] = out.Len()
+	fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", infoID, m.infoDictionary())
+
+	xmp := m.xmpPacket(id)
+	offsets[xmpID] = out.Len()
+	fmt.Fprintf(&out, "%d 0 obj\n<< /Type /Metadata /Subtype /XML /Length %d >>\nstream\n%s\nendstream\nendobj\n",
+		xmpID, len(xmp), xmp)
+
+	xref := out.Len()
+	out.WriteString("xref\n")
+	fmt.Fprintf(&out, "1 1\n%010d 00000 n \n", offsets[1])
+	fmt.Fprintf(&out, "%d 2\n%010d 00000 n \n%010d 00000 n \n", infoID, offsets[infoID], offsets[xmpID])
+
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R /Prev %s /ID [<%s> <%s>] >>\n",
+		xmpID+1, infoID, startxref[1], id, id)
+	fmt.Fprintf(&out, "startxref\n%d\n%%%%EOF\n", xref)
+
+	return out.Bytes(), nil
+}
+
+// infoDictionary formats the document information dictionary
+func (m Metadata) infoDictionary() string {
+	var b strings.Builder
+	b.WriteString("<<\n")
+	entry := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "  /%s %s\n", key, textString(value))
+		}
+	}
+	entry("Title", m.Title)
+	entry("Author", m.Author)
+	entry("Subject", m.Subject)
+	entry("Keywords", strings.Join(m.Keywords, ", "))
+	entry("Creator", m.Creator)
+	entry("Producer", m.Producer)
+	if !m.CreationDate.IsZero() {
+		fmt.Fprintf(&b, "  /CreationDate (%s)\n", pdfDate(m.CreationDate))
+	}
+	if !m.ModDate.IsZero() {
+		fmt.Fprintf(&b, "  /ModDate (%s)\n", pdfDate(m.ModDate))
+	}
+	b.WriteString(">>")
+	return b.String()
+}
+
+// textString encodes s as a UTF-16BE PDF text string
+func textString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", unit)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// pdfDate formats t as a PDF date string, D:YYYYMMDDHHmmSS with its offset
+func pdfDate(t time.Time) string {
+	date := t.Format("D:20060102150405")
+	_, offset := t.Zone()
+	if offset == 0 {
+		return date + "Z"
+	}
+
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	return fmt.Sprintf("%s%c%02d'%02d'", date, sign, offset/3600, offset/60%60)
+}
+
+// documentID derives the file identifier from the content and metadata so
+// that the same input always produces the same identifier
+func documentID(data []byte, m Metadata) string {
+	h := md5.New()
+	h.Write(data)
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s", m.Title, m.Author, m.Subject,
+		strings.Join(m.Keywords, ","), m.CreationDate.UTC().Format(time.RFC3339))
+	return fmt.Sprintf("%X", h.Sum(nil))
+}
+
+// xmpPacket formats the metadata as an XMP packet mirroring the information
+// dictionary, identified by the document ID
+func (m Metadata) xmpPacket(id string) string {
+	var b strings.Builder
+	esc := func(s string) string {
+		var e bytes.Buffer
+		xml.EscapeText(&e, []byte(s))
+		return e.String()
+	}
+	xmpDate := func(t time.Time) string {
+		return t.Format("2006-01-02T15:04:05Z07:00")
+	}
+
+	b.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString(" <rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	b.WriteString("  <rdf:Description rdf:about=\"\"\n")
+	b.WriteString("    xmlns:dc=\"http://purl.org/dc/elements/1.1/\"\n")
+	b.WriteString("    xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\"\n")
+	b.WriteString("    xmlns:pdf=\"http://ns.adobe.com/pdf/1.3/\"\n")
+	b.WriteString("    xmlns:xmpMM=\"http://ns.adobe.com/xap/1.0/mm/\">\n")
+	b.WriteString("   <dc:format>application/pdf</dc:format>\n")
+
+	if m.Title != "" {
+		fmt.Fprintf(&b, "   <dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:title>\n", esc(m.Title))
+	}
+	if m.Author != "" {
+		fmt.Fprintf(&b, "   <dc:creator><rdf:Seq><rdf:li>%s</rdf:li></rdf:Seq></dc:creator>\n", esc(m.Author))
+	}
+	if m.Subject != "" {
+		fmt.Fprintf(&b, "   <dc:description><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:description>\n", esc(m.Subject))
+	}
+	if len(m.Keywords) > 0 {
+		b.WriteString("   <dc:subject><rdf:Bag>")
+		for _, keyword := range m.Keywords {
+			fmt.Fprintf(&b, "<rdf:li>%s</rdf:li>", esc(keyword))
+		}
+		b.WriteString("</rdf:Bag></dc:subject>\n")
+		fmt.Fprintf(&b, "   <pdf:Keywords>%s</pdf:Keywords>\n", esc(strings.Join(m.Keywords, ", ")))
+	}
+	if m.Producer != "" {
+		fmt.Fprintf(&b, "   <pdf:Producer>%s</pdf:Producer>\n", esc(m.Producer))
+	}
+	if m.Creator != "" {
+		fmt.Fprintf(&b, "   <xmp:CreatorTool>%s</xmp:CreatorTool>\n", esc(m.Creator))
+	}
+	if !m.CreationDate.IsZero() {
+		fmt.Fprintf(&b, "   <xmp:CreateDate>%s</xmp:CreateDate>\n", xmpDate(m.CreationDate))
+	}
+	if !m.ModDate.IsZero() {
+		fmt.Fprintf(&b, "   <xmp:ModifyDate>%s</xmp:ModifyDate>\n", xmpDate(m.ModDate))
+		fmt.Fprintf(&b, "   <xmp:MetadataDate>%s</xmp:MetadataDate>\n", xmpDate(m.ModDate))
+	}
+	fmt.Fprintf(&b, "   <xmpMM:DocumentID>uuid:%s</xmpMM:DocumentID>\n", uuid(id))
+	fmt.Fprintf(&b, "   <xmpMM:InstanceID>uuid:%s</xmpMM:InstanceID>\n", uuid(id))
+
+	b.WriteString("  </rdf:Description>\n")
+	b.WriteString(" </rdf:RDF>\n")
+	b.WriteString("</x:xmpmeta>\n")
+	b.WriteString("<?xpacket end=\"w\"?>")
+	return b.String()
+}
+
+// uuid formats a 32 digit hex identifier in the 8-4-4-4-12 UUID layout
+func uuid(id string) string {
+	id = strings.ToLower(id)
+	return id[0:8] + "-" + id[8:12] + "-" + id[12:16] + "-" + id[16:20] + "-" + id[20:32]
+}
@@ -0,0 +1,134 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/signintech/gopdf"
+)
+
+var (
+	outlinesPattern = regexp.MustCompile(`/Outlines (\d+) 0 R`)
+
+	// outlineLinkPattern matches the lines of an outline entry that link it
+	// into the tree
+	outlineLinkPattern = regexp.MustCompile(`(?m)^\s*/(Parent|Prev|Next|First|Last|Count) .*\n`)
+)
+
+// AddOutline adds a bookmark for position y on the current page. Level 1 is
+// the top of the tree; an entry nests under the closest preceding entry with
+// a lower level.
+func (g *Generator) AddOutline(title string, level int, y float64) {
+	g.note(func(_, dy float64) {
+		g.addOutline(title, level, y+dy)
+	})
+}
+
+func (g *Generator) addOutline(title string, level int, y float64) {
+	// gopdf places the destination 20pt above y so the heading stays in view
+	g.pdf.SetY(g.PageHeight - y)
+	node := &gopdf.OutlineNode{Obj: g.pdf.AddOutlineWithPosition(title)}
+
+	for len(g.outlineStack) > 0 && g.outlineLevels[len(g.outlineLevels)-1] >= level {
+		g.outlineStack = g.outlineStack[:len(g.outlineStack)-1]
+		g.outlineLevels = g.outlineLevels[:len(g.outlineLevels)-1]
+	}
+
+	if len(g.outlineStack) == 0 {
+		g.outlineRoots = append(g.outlineRoots, node)
+	} else {
+		parent := g.outlineStack[len(g.outlineStack)-1]
+		parent.Children = append(parent.Children, node)
+	}
+
+	g.outlineStack = append(g.outlineStack, node)
+	g.outlineLevels = append(g.outlineLevels, level)
+}
+
+// appendOutlines rewrites the bookmark tree of a PDF written by gopdf as
+// an incremental update. gopdf links top-level entries in the order they
+// were added whatever their level, gives them the outline dictionary's
+// index rather than its object number as parent and writes no /Count for
+// entries with children, so the update redefines the outline dictionary and
+// every entry with the links of the tree. Entries are open, so each /Count
+// is the number of entries under it.
+func (g *Generator) appendOutlines(data []byte) ([]byte, error) {
+	if len(g.outlineRoots) == 0 {
+		return data, nil
+	}
+	outlines := outlinesPattern.FindSubmatch(data)
+	sizes := sizePattern.FindAllSubmatch(data, -1)
+	startxref := startxrefPattern.FindSubmatch(data)
+	if outlines == nil || sizes == nil || startxref == nil {
+		return nil, fmt.Errorf("unexpected PDF structure from gopdf")
+	}
+	outlinesID, _ := strconv.Atoi(string(outlines[1]))
+
+	var out bytes.Buffer
+	out.Write(data)
+	offsets := make(map[int]int)
+
+	// writeEntries writes a list of entries under a parent and returns how
+	// many entries it holds, counting those nested in it
+	var writeEntries func(nodes []*gopdf.OutlineNode, parent int) (int, error)
+	writeEntries = func(nodes []*gopdf.OutlineNode, parent int) (int, error) {
+		total := 0
+		for i, node := range nodes {
+			id := node.Obj.GetIndex()
+			body := regexp.MustCompile(fmt.Sprintf(`(?s)\n%d 0 obj\n<<\n(.*?)>>\nendobj`, id)).FindSubmatch(data)
+			if body == nil {
+				return 0, fmt.Errorf("outline entry %d not found in PDF", id)
+			}
+
+			var entry bytes.Buffer
+			fmt.Fprintf(&entry, "  /Parent %d 0 R\n", parent)
+			if i > 0 {
+				fmt.Fprintf(&entry, "  /Prev %d 0 R\n", nodes[i-1].Obj.GetIndex())
+			}
+			if i < len(nodes)-1 {
+				fmt.Fprintf(&entry, "  /Next %d 0 R\n", nodes[i+1].Obj.GetIndex())
+			}
+			count, err := writeEntries(node.Children, id)
+			if err != nil {
+				return 0, err
+			}
+			if count > 0 {
+				fmt.Fprintf(&entry, "  /First %d 0 R\n  /Last %d 0 R\n  /Count %d\n",
+					node.Children[0].Obj.GetIndex(), node.Children[len(node.Children)-1].Obj.GetIndex(), count)
+			}
+			entry.Write(outlineLinkPattern.ReplaceAll(body[1], nil))
+
+			offsets[id] = out.Len()
+			fmt.Fprintf(&out, "%d 0 obj\n<<\n%s>>\nendobj\n", id, entry.Bytes())
+			total += 1 + count
+		}
+		return total, nil
+	}
+
+	count, err := writeEntries(g.outlineRoots, outlinesID)
+	if err != nil {
+		return nil, err
+	}
+	offsets[outlinesID] = out.Len()
+	fmt.Fprintf(&out, "%d 0 obj\n<<\n  /Type /Outlines\n  /First %d 0 R\n  /Last %d 0 R\n  /Count %d\n>>\nendobj\n",
+		outlinesID, g.outlineRoots[0].Obj.GetIndex(), g.outlineRoots[len(g.outlineRoots)-1].Obj.GetIndex(), count)
+
+	ids := make([]int, 0, len(offsets))
+	for id := range offsets {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	xref := out.Len()
+	out.WriteString("xref\n")
+	for _, id := range ids {
+		fmt.Fprintf(&out, "%d 1\n%010d 00000 n \n", id, offsets[id])
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %s /Root 1 0 R /Prev %s >>\n", sizes[len(sizes)-1][1], startxref[1])
+	fmt.Fprintf(&out, "startxref\n%d\n%%%%EOF\n", xref)
+
+	return out.Bytes(), nil
+}
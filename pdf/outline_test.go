@@ -0,0 +1,141 @@
+package pdf_test
+
+import (
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/rickykimani/gotex/lexer"
+	"github.com/rickykimani/gotex/macro"
+	"github.com/rickykimani/gotex/parser"
+	"github.com/rickykimani/gotex/pdf"
+	"github.com/rickykimani/gotex/processor"
+	"github.com/rickykimani/gotex/ttf"
+)
+
+const outlineInput = `\documentclass{article}
+\begin{document}
+\section{A}
+\subsection{A1}
+\subsection{A2}
+\section{B}
+\section{C}
+\subsection{C1}
+\end{document}`
+
+var (
+	objectPattern = regexp.MustCompile(`(?s)\n(\d+) 0 obj\n(.*?)\nendobj`)
+	titlePattern  = regexp.MustCompile(`/Title <FEFF([0-9A-F]*)>`)
+)
+
+// pdfObjects returns the objects of a PDF by object number, taking the
+// last definition of each as a reader applying the updates would
+func pdfObjects(data []byte) map[string]string {
+	objects := make(map[string]string)
+	for _, m := range objectPattern.FindAllSubmatch(data, -1) {
+		objects[string(m[1])] = string(m[2])
+	}
+	return objects
+}
+
+// entry returns the value of a key in a dictionary: the object number of a
+// reference or the number of /Count, or "" if the key is missing
+func entry(dict, key string) string {
+	m := regexp.MustCompile(`/` + key + ` (\d+)`).FindStringSubmatch(dict)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+func TestOutlineTree(t *testing.T) {
+	doc, _ := parser.NewParser(lexer.NewLexer(outlineInput).Tokenize()).Parse()
+	store := macro.NewMacroStore(nil)
+	store.AddBuiltins()
+	expanded := macro.NewExpander(store).ExpandDocument(doc)
+
+	generator, err := pdf.NewGenerator(ttf.FS)
+	if err != nil {
+		t.Fatalf("creating generator: %v", err)
+	}
+	processor.NewDocumentProcessor(generator).ProcessDocument(expanded.Body)
+	data, err := generator.Bytes()
+	if err != nil {
+		t.Fatalf("writing PDF: %v", err)
+	}
+
+	objects := pdfObjects(data)
+	ids := map[string]string{} // Object numbers by title
+	for id, dict := range objects {
+		m := titlePattern.FindStringSubmatch(dict)
+		if m == nil {
+			continue
+		}
+		raw, _ := hex.DecodeString(m[1])
+		units := make([]uint16, len(raw)/2)
+		for i := range units {
+			units[i] = uint16(raw[2*i])<<8 | uint16(raw[2*i+1])
+		}
+		ids[string(utf16.Decode(units))] = id
+	}
+
+	root := entry(objects["1"], "Outlines")
+	if root == "" {
+		t.Fatalf("catalog has no /Outlines")
+	}
+	if !strings.Contains(objects[root], "/Type /Outlines") {
+		t.Fatalf("object %s is not the outline dictionary: %q", root, objects[root])
+	}
+
+	// Each entry's expected parent, previous, next, first and last child as
+	// titles, and count
+	tests := []struct {
+		title, parent, prev, next, first, last, count string
+	}{
+		{title: "1 A", parent: "", next: "2 B", first: "1.1 A1", last: "1.2 A2", count: "2"},
+		{title: "1.1 A1", parent: "1 A", next: "1.2 A2"},
+		{title: "1.2 A2", parent: "1 A", prev: "1.1 A1"},
+		{title: "2 B", parent: "", prev: "1 A", next: "3 C"},
+		{title: "3 C", parent: "", prev: "2 B", first: "3.1 C1", last: "3.1 C1", count: "1"},
+		{title: "3.1 C1", parent: "3 C"},
+	}
+
+	if got := entry(objects[root], "First"); got != ids["1 A"] {
+		t.Errorf("outlines /First = %s, expected %s", got, ids["1 A"])
+	}
+	if got := entry(objects[root], "Last"); got != ids["3 C"] {
+		t.Errorf("outlines /Last = %s, expected %s", got, ids["3 C"])
+	}
+	if got := entry(objects[root], "Count"); got != "6" {
+		t.Errorf("outlines /Count = %s, expected 6", got)
+	}
+
+	for _, tt := range tests {
+		id, ok := ids[tt.title]
+		if !ok {
+			t.Errorf("no outline entry titled %q", tt.title)
+			continue
+		}
+		dict := objects[id]
+
+		parent := root
+		if tt.parent != "" {
+			parent = ids[tt.parent]
+		}
+		links := map[string]string{
+			"Parent": parent,
+			"Prev":   ids[tt.prev],
+			"Next":   ids[tt.next],
+			"First":  ids[tt.first],
+			"Last":   ids[tt.last],
+			"Count":  tt.count,
+		}
+		for key, expected := range links {
+			if got := entry(dict, key); got != expected {
+				t.Errorf("%q: /%s = %q, expected %q", tt.title, key, got, expected)
+			}
+		}
+	}
+}
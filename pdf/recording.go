@@ -0,0 +1,105 @@
+package pdf
+
+// operation draws something recorded earlier, moved right by dx and up by dy
+// points
+type operation func(dx, dy float64)
+
+// Recording is drawing recorded apart from the main recording, such as a
+// float that is placed on a later page than where it appears in the source
+type Recording struct {
+	operations []operation
+}
+
+// StartRecording makes drawing methods record their operations instead of
+// drawing them, so that content can be laid out before it is known which
+// page it goes on. Replay draws recorded operations onto the current page.
+func (g *Generator) StartRecording() {
+	g.recording = true
+}
+
+// StopRecording makes drawing methods draw on the current page again
+func (g *Generator) StopRecording() {
+	g.recording = false
+}
+
+// Recorded returns the number of operations recorded so far. Operations are
+// numbered in the order they were recorded.
+func (g *Generator) Recorded() int {
+	return len(g.operations)
+}
+
+// Replay draws the recorded operations from up to to on the current page,
+// moved by dx and dy. Each operation can be replayed once.
+func (g *Generator) Replay(from, to int, dx, dy float64) {
+	for i := from; i < to; i++ {
+		if op := g.operations[i]; op != nil {
+			op(dx, dy)
+			g.operations[i] = nil
+		}
+	}
+}
+
+// Record records what draw draws into a Recording of its own, leaving the
+// main recording and the recorded color as they were
+func (g *Generator) Record(draw func()) *Recording {
+	operations, recording, color := g.operations, g.recording, g.recordedColor
+	g.operations, g.recording = nil, true
+	if !recording {
+		g.recordedColor = g.color
+	}
+
+	draw()
+
+	r := &Recording{operations: g.operations}
+	g.operations, g.recording, g.recordedColor = operations, recording, color
+	g.drawn = g.drawn[:len(g.operations)]
+	return r
+}
+
+// ReplayRecording draws r on the current page, moved by dx and dy. Colors
+// set in r do not carry over to what is drawn after it.
+func (g *Generator) ReplayRecording(r *Recording, dx, dy float64) {
+	color := g.color
+	for _, op := range r.operations {
+		op(dx, dy)
+	}
+	r.operations = nil
+	g.applyColor(color)
+}
+
+// Draws reports whether any of the recorded operations from up to to draws
+// on the page, rather than only noting where something ended up
+func (g *Generator) Draws(from, to int) bool {
+	for i := from; i < to; i++ {
+		if g.drawn[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// Defer runs f when the operations recorded before it are replayed, or
+// immediately when not recording. It lets callers note on which page
+// something ended up.
+func (g *Generator) Defer(f func()) {
+	g.note(func(float64, float64) { f() })
+}
+
+// draw records op, or performs it straight away when not recording
+func (g *Generator) draw(op operation) {
+	g.record(op, true)
+}
+
+// note records op, which draws nothing but notes where it was replayed
+func (g *Generator) note(op operation) {
+	g.record(op, false)
+}
+
+func (g *Generator) record(op operation, drawn bool) {
+	if g.recording {
+		g.operations = append(g.operations, op)
+		g.drawn = append(g.drawn, drawn)
+		return
+	}
+	op(0, 0)
+}
@@ -0,0 +1,190 @@
+package processor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rickykimani/gotex/parser"
+	"github.com/rickykimani/gotex/pdf"
+)
+
+// fboxSep is the padding between the content and edge of \colorbox and \fcolorbox
+const fboxSep = 3.0
+
+// currentColor returns the color at the top of the color stack
+func (dp *DocumentProcessor) currentColor() pdf.Color {
+	if len(dp.colorStack) == 0 {
+		return pdf.Black
+	}
+	return dp.colorStack[len(dp.colorStack)-1]
+}
+
+// pushColor makes c the current color until the enclosing group ends
+func (dp *DocumentProcessor) pushColor(c pdf.Color) {
+	dp.colorStack = append(dp.colorStack, c)
+	dp.generator.SetColor(c)
+}
+
+// colorDepth marks the start of a group so its colors can be dropped
+// with restoreColor when the group ends
+func (dp *DocumentProcessor) colorDepth() int {
+	return len(dp.colorStack)
+}
+
+// restoreColor pops colors pushed since depth and reinstates the color
+// that was current when the group started
+func (dp *DocumentProcessor) restoreColor(depth int) {
+	if depth >= len(dp.colorStack) {
+		return
+	}
+	dp.colorStack = dp.colorStack[:depth]
+	dp.generator.SetColor(dp.currentColor())
+}
+
+// resolveColor turns a color argument into a color. With a model, spec is a
+// value in that model; otherwise it is a name or an xcolor expression such
+// as red!30 or red!30!blue.
+func (dp *DocumentProcessor) resolveColor(model, spec string) (pdf.Color, error) {
+	if model != "" {
+		return parseColorModel(model, spec)
+	}
+
+	parts := strings.Split(strings.TrimSpace(spec), "!")
+	color, err := dp.namedColor(parts[0])
+	if err != nil {
+		return pdf.Color{}, err
+	}
+
+	// Each !percent!name step mixes the result so far with the next color,
+	// which defaults to white
+	for i := 1; i < len(parts); i += 2 {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(parts[i]), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return pdf.Color{}, fmt.Errorf("invalid color expression %q", spec)
+		}
+
+		other := namedColors["white"]
+		if i+1 < len(parts) {
+			if other, err = dp.namedColor(parts[i+1]); err != nil {
+				return pdf.Color{}, err
+			}
+		}
+		color = mixColors(color, other, percent)
+	}
+
+	return color, nil
+}
+
+func (dp *DocumentProcessor) namedColor(name string) (pdf.Color, error) {
+	name = strings.TrimSpace(name)
+	if color, ok := dp.definedColors[name]; ok {
+		return color, nil
+	}
+	if color, ok := namedColors[name]; ok {
+		return color, nil
+	}
+	return pdf.Color{}, fmt.Errorf("undefined color %q", name)
+}
+
+// colorArgument resolves the color given by a command's optional model and
+// its argument at index i, warning and falling back to the current color
+// when it is invalid
+func (dp *DocumentProcessor) colorArgument(cmd *parser.Command, i int) pdf.Color {
+	model := ""
+	if len(cmd.Optional) > 0 {
+		model = strings.TrimSpace(dp.extractText(cmd.Optional[0]))
+	}
+
+	color, err := dp.resolveColor(model, dp.extractText(cmd.Args[i]))
+	if err != nil {
+		dp.warn("\\%s: %v", cmd.Name, err)
+		return dp.currentColor()
+	}
+	return color
+}
+
+// processColorCommand handles the xcolor commands
+func (dp *DocumentProcessor) processColorCommand(cmd *parser.Command, style string) {
+	switch cmd.Name {
+	case "color":
+		// Declaration: lasts until the enclosing group ends
+		if len(cmd.Args) > 0 {
+			dp.pushColor(dp.colorArgument(cmd, 0))
+		}
+
+	case "textcolor":
+		if len(cmd.Args) < 2 {
+			return
+		}
+		depth := dp.colorDepth()
+		dp.pushColor(dp.colorArgument(cmd, 0))
+		dp.processStyledText(cmd.Args[1], style)
+		dp.restoreColor(depth)
+
+	case "definecolor":
+		if len(cmd.Args) < 3 {
+			return
+		}
+		name := strings.TrimSpace(dp.extractText(cmd.Args[0]))
+		model := strings.TrimSpace(dp.extractText(cmd.Args[1]))
+		color, err := parseColorModel(model, dp.extractText(cmd.Args[2]))
+		if err != nil {
+			dp.warn("\\definecolor{%s}: %v", name, err)
+			return
+		}
+		dp.definedColors[name] = color
+
+	case "colorlet":
+		if len(cmd.Args) < 2 {
+			return
+		}
+		name := strings.TrimSpace(dp.extractText(cmd.Args[0]))
+		color, err := dp.resolveColor("", dp.extractText(cmd.Args[1]))
+		if err != nil {
+			dp.warn("\\colorlet{%s}: %v", name, err)
+			return
+		}
+		dp.definedColors[name] = color
+
+	case "colorbox":
+		if len(cmd.Args) < 2 {
+			return
+		}
+		background := dp.colorArgument(cmd, 0)
+		dp.addColorBox(cmd.Args[1], style, background, nil)
+
+	case "fcolorbox":
+		if len(cmd.Args) < 3 {
+			return
+		}
+		frame := dp.colorArgument(cmd, 0)
+		background := dp.colorArgument(cmd, 1)
+		dp.addColorBox(cmd.Args[2], style, background, &frame)
+	}
+}
+
+// addColorBox draws content on a colored background, optionally framed.
+// Like an \hbox, the box is kept on one line.
+func (dp *DocumentProcessor) addColorBox(content parser.Node, style string, background pdf.Color, frame *pdf.Color) {
+	text := applyLigatures(dp.extractText(content), style)
+	width := dp.calculateTextWidth(text, style) + 2*fboxSep
+
+	if dp.lineHasContent && (dp.currentLineX+width > dp.generator.PageWidth-dp.generator.MarginRight) {
+		dp.newLine()
+	}
+
+	// Cover the font's ascent and descent plus the padding
+	bottom := dp.currentY - dp.fontSize*0.25 - fboxSep
+	height := dp.fontSize + 2*fboxSep
+	if frame != nil {
+		dp.generator.AddFramedRect(dp.currentLineX, bottom, width, height, *frame, background)
+	} else {
+		dp.generator.AddFilledRect(dp.currentLineX, bottom, width, height, background)
+	}
+
+	dp.currentLineX += fboxSep
+	dp.lineHasContent = true
+	dp.processStyledText(content, style)
+	dp.currentLineX += fboxSep
+}
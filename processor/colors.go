@@ -0,0 +1,118 @@
+package processor
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/rickykimani/gotex/pdf"
+)
+
+// namedColors holds the colors xcolor predefines without options
+var namedColors = map[string]pdf.Color{
+	"black":     {R: 0, G: 0, B: 0},
+	"white":     {R: 255, G: 255, B: 255},
+	"red":       {R: 255, G: 0, B: 0},
+	"green":     {R: 0, G: 255, B: 0},
+	"blue":      {R: 0, G: 0, B: 255},
+	"cyan":      {R: 0, G: 255, B: 255},
+	"magenta":   {R: 255, G: 0, B: 255},
+	"yellow":    {R: 255, G: 255, B: 0},
+	"gray":      {R: 128, G: 128, B: 128},
+	"darkgray":  {R: 64, G: 64, B: 64},
+	"lightgray": {R: 191, G: 191, B: 191},
+	"brown":     {R: 191, G: 128, B: 64},
+	"lime":      {R: 191, G: 255, B: 0},
+	"olive":     {R: 128, G: 128, B: 0},
+	"orange":    {R: 255, G: 128, B: 0},
+	"pink":      {R: 255, G: 191, B: 191},
+	"purple":    {R: 191, G: 0, B: 64},
+	"teal":      {R: 0, G: 128, B: 128},
+	"violet":    {R: 128, G: 0, B: 128},
+}
+
+// parseColorModel converts a color specification in one of the xcolor
+// models rgb, RGB, HTML, gray or cmyk to an RGB color
+func parseColorModel(model, spec string) (pdf.Color, error) {
+	spec = strings.TrimSpace(spec)
+
+	switch model {
+	case "rgb":
+		v, err := parseComponents(spec, 3, 1)
+		if err != nil {
+			return pdf.Color{}, err
+		}
+		return pdf.Color{R: toByte(v[0]), G: toByte(v[1]), B: toByte(v[2])}, nil
+
+	case "RGB":
+		v, err := parseComponents(spec, 3, 255)
+		if err != nil {
+			return pdf.Color{}, err
+		}
+		return pdf.Color{R: toByte(v[0] / 255), G: toByte(v[1] / 255), B: toByte(v[2] / 255)}, nil
+
+	case "HTML":
+		hex := strings.TrimPrefix(spec, "#")
+		value, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return pdf.Color{}, fmt.Errorf("invalid HTML color %q", spec)
+		}
+		return pdf.Color{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value)}, nil
+
+	case "gray":
+		v, err := parseComponents(spec, 1, 1)
+		if err != nil {
+			return pdf.Color{}, err
+		}
+		level := toByte(v[0])
+		return pdf.Color{R: level, G: level, B: level}, nil
+
+	case "cmyk":
+		v, err := parseComponents(spec, 4, 1)
+		if err != nil {
+			return pdf.Color{}, err
+		}
+		k := 1 - v[3]
+		return pdf.Color{
+			R: toByte((1 - v[0]) * k),
+			G: toByte((1 - v[1]) * k),
+			B: toByte((1 - v[2]) * k),
+		}, nil
+
+	default:
+		return pdf.Color{}, fmt.Errorf("unsupported color model %q", model)
+	}
+}
+
+// parseComponents parses n comma or space separated numbers in [0, limit]
+func parseComponents(spec string, n int, limit float64) ([]float64, error) {
+	fields := strings.FieldsFunc(spec, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(fields) != n {
+		return nil, fmt.Errorf("expected %d color components in %q", n, spec)
+	}
+
+	values := make([]float64, n)
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil || v < 0 || v > limit {
+			return nil, fmt.Errorf("invalid color component %q", field)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// toByte scales a [0, 1] channel to [0, 255]
+func toByte(v float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+}
+
+// mixColors blends a with b, taking percent of a
+func mixColors(a, b pdf.Color, percent float64) pdf.Color {
+	t := percent / 100
+	blend := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x)*t + float64(y)*(1-t)))
+	}
+	return pdf.Color{R: blend(a.R, b.R), G: blend(a.G, b.G), B: blend(a.B, b.B)}
+}
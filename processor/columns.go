@@ -0,0 +1,110 @@
+package processor
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rickykimani/gotex/lexer"
+	"github.com/rickykimani/gotex/parser"
+)
+
+// multicolSep is the space above and below a multicols environment
+const multicolSep = 12.0
+
+// setColumns sets the text that follows in n columns. Columns are laid out
+// at the width of one column where the first one goes; the page builder
+// moves the others across.
+func (dp *DocumentProcessor) setColumns(n int) {
+	if n == dp.columns {
+		return
+	}
+	dp.closeBox()
+	dp.columns = n
+	dp.run++
+	dp.generator.MarginRight = dp.generator.PageWidth - dp.generator.MarginLeft - dp.columnWidth(n)
+}
+
+// columnWidth is the width of each of n columns across the text width
+func (dp *DocumentProcessor) columnWidth(n int) float64 {
+	return (dp.textWidth - float64(n-1)*columnSep) / float64(n)
+}
+
+// processColumnCommand handles \twocolumn and \onecolumn, which start a new
+// page. The optional argument of \twocolumn spans both columns at the top.
+func (dp *DocumentProcessor) processColumnCommand(cmd *parser.Command, style string) {
+	if dp.lineHasContent || len(dp.galley) > 1 || dp.pagesShipped > 0 {
+		dp.breakPage("clearpage", 4)
+	}
+	if cmd.Name == "onecolumn" {
+		dp.setColumns(1)
+		return
+	}
+
+	if len(cmd.Optional) > 0 {
+		dp.setColumns(1)
+		dp.processNodes(dp.parseOptional(cmd.Optional[0]), style)
+		if dp.lineHasContent {
+			dp.newLine()
+		}
+		dp.addVerticalSpace(dp.lineHeight * 0.5)
+	}
+	dp.setColumns(2)
+}
+
+// processMulticols sets the body of a multicols environment in columns,
+// balanced at its end. The optional argument after the number of columns
+// spans them at the top.
+func (dp *DocumentProcessor) processMulticols(env *parser.Environment, style string) {
+	n := 0
+	if len(env.Args) > 0 {
+		n, _ = strconv.Atoi(strings.TrimSpace(dp.extractText(env.Args[0])))
+	}
+	if n < 2 || n > 10 {
+		dp.warn("multicols needs 2 to 10 columns, got %d", n)
+		n = min(max(n, 1), 10)
+	}
+
+	if dp.lineHasContent {
+		dp.newLine()
+	}
+	dp.endParagraph()
+	dp.addVerticalSpace(multicolSep)
+	if len(env.Optional) > 0 {
+		dp.processNodes(dp.parseOptional(env.Optional[0]), style)
+		if dp.lineHasContent {
+			dp.newLine()
+		}
+		dp.addVerticalSpace(dp.lineHeight * 0.5)
+	}
+
+	previous := dp.columns
+	dp.setColumns(n)
+	dp.processNodes(env.Body, style)
+	if dp.lineHasContent {
+		dp.newLine()
+	}
+	dp.setColumns(previous)
+	dp.addVerticalSpace(multicolSep)
+	dp.endParagraph()
+}
+
+// addSpanning sets what draw draws across all columns, the way two-column
+// documents set their title
+func (dp *DocumentProcessor) addSpanning(draw func()) {
+	columns := dp.columns
+	dp.setColumns(1)
+	draw()
+	dp.setColumns(columns)
+}
+
+// parseOptional parses an optional argument as LaTeX. The lexer keeps
+// optional arguments as raw text, which is enough for options but not for
+// the headers of \twocolumn and multicols.
+func (dp *DocumentProcessor) parseOptional(node parser.Node) []parser.Node {
+	text, ok := node.(*parser.TextNode)
+	if !ok {
+		return []parser.Node{node}
+	}
+	doc, _ := parser.NewParser(lexer.NewLexer(text.Value).Tokenize()).Parse()
+	return doc.Body
+}
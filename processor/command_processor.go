@@ -1,50 +1,76 @@
 package processor
 
-import "github.com/rickykimani/gotex/parser"
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rickykimani/gotex/parser"
+)
 
 func (dp *DocumentProcessor) processCommand(cmd *parser.Command, style string) {
 	switch cmd.Name {
 	case "documentclass":
-		// Skip document class
+		// Only the page layout options are used
 		//TODO: Implement different document classes
+		if len(cmd.Optional) > 0 {
+			for _, option := range strings.Split(dp.extractText(cmd.Optional[0]), ",") {
+				switch strings.TrimSpace(option) {
+				case "twoside":
+					dp.page.twoside = true
+				case "oneside":
+					dp.page.twoside = false
+				case "twocolumn":
+					dp.setColumns(2)
+				case "onecolumn":
+					dp.setColumns(1)
+				}
+			}
+		}
 
 	case "usepackage":
-		// Skip packages for now
+		// Skip packages for now, apart from hyperref's options
 		//TODO: Implement universally compatible package system
+		if len(cmd.Args) > 0 && len(cmd.Optional) > 0 && dp.extractText(cmd.Args[0]) == "hyperref" {
+			dp.applyHyperrefOptions(dp.extractText(cmd.Optional[0]))
+		}
 
 	case "title":
 		if len(cmd.Args) > 0 {
-			dp.title = dp.extractText(cmd.Args[0])
+			dp.title = dp.extractTypesetText(cmd.Args[0])
 		}
 
 	case "author":
 		if len(cmd.Args) > 0 {
-			dp.author = dp.extractText(cmd.Args[0])
+			dp.author = dp.extractTypesetText(cmd.Args[0])
 		}
 
 	case "date":
 		if len(cmd.Args) > 0 {
-			dp.date = dp.extractText(cmd.Args[0])
+			dp.date = dp.extractTypesetText(cmd.Args[0])
 		}
 
 	case "maketitle":
-		dp.addTitle()
+		dp.generator.Defer(func() {
+			dp.page.thisStyle = "plain"
+		})
+		// Two-column documents set the title across both columns
+		dp.addSpanning(dp.addTitle)
 
 	case "section":
 		if len(cmd.Args) > 0 {
-			text := dp.extractText(cmd.Args[0])
+			text := dp.extractTypesetText(cmd.Args[0])
 			dp.addSection(text)
 		}
 
 	case "subsection":
 		if len(cmd.Args) > 0 {
-			text := dp.extractText(cmd.Args[0])
+			text := dp.extractTypesetText(cmd.Args[0])
 			dp.addSubsection(text)
 		}
 
 	case "subsubsection":
 		if len(cmd.Args) > 0 {
-			text := dp.extractText(cmd.Args[0])
+			text := dp.extractTypesetText(cmd.Args[0])
 			dp.addSubsubsection(text)
 		}
 
@@ -71,6 +97,130 @@ func (dp *DocumentProcessor) processCommand(cmd *parser.Command, style string) {
 			dp.processStyledText(cmd.Args[0], newStyle)
 		}
 
+	case "emph":
+		if len(cmd.Args) > 0 {
+			dp.processStyledText(cmd.Args[0], emphasize(style))
+		}
+
+	case "texttt":
+		if len(cmd.Args) > 0 {
+			dp.processStyledText(cmd.Args[0], "mono")
+		}
+
+	case "em":
+		// Declaration form; processNodes applies it to the rest of the group
+
+	case "color", "textcolor", "definecolor", "colorlet", "colorbox", "fcolorbox":
+		dp.processColorCommand(cmd, style)
+
+	case "href", "url", "hypersetup":
+		dp.processHyperrefCommand(cmd, style)
+
+	case "label":
+		// Resolved ahead of time by collectReferences
+
+	case "ref":
+		if len(cmd.Args) > 0 {
+			dp.addReference(dp.extractText(cmd.Args[0]), "%s", style)
+		}
+
+	case "eqref":
+		if len(cmd.Args) > 0 {
+			dp.addReference(dp.extractText(cmd.Args[0]), "(%s)", style)
+		}
+
+	case "cite":
+		dp.addCitation(cmd, style)
+
+	case "bibitem":
+		dp.addBibItem(cmd)
+
+	case "pagestyle", "thispagestyle", "pagenumbering", "markboth", "markright",
+		"fancyhf", "fancyhead", "fancyfoot", "lhead", "chead", "rhead", "lfoot", "cfoot", "rfoot":
+		dp.processPageStyleCommand(cmd)
+
+	case "newpage", "clearpage", "pagebreak", "nopagebreak":
+		priority := 4
+		if len(cmd.Optional) > 0 {
+			if n, err := strconv.Atoi(strings.TrimSpace(dp.extractText(cmd.Optional[0]))); err == nil {
+				priority = n
+			}
+		}
+		dp.breakPage(cmd.Name, priority)
+
+	case "twocolumn", "onecolumn":
+		dp.processColumnCommand(cmd, style)
+
+	case "columnbreak":
+		if dp.columns > 1 {
+			dp.addPenalty(forcedBreak)
+		}
+
+	case "caption":
+		dp.addCaption(cmd, style)
+
+	case "hspace", "hspace*", "vspace", "vspace*", "hfil", "hfill", "quad", "qquad",
+		",", ":", ";", "!", "thinspace", "negthinspace", "medspace", "thickspace", "enspace", "enskip",
+		"smallskip", "medskip", "bigskip", "indent", "noindent":
+		dp.processSpacingCommand(cmd)
+
+	case "\\", "\\*", "newline":
+		dp.breakLine(cmd)
+
+	case "%", "$", "&", "#", "_", "{", "}":
+		dp.addWord(escapedCharacters[cmd.Name], style)
+
+	case "textquotedbl", "textquotesingle", "textquoteleft", "textquoteright", "textquotedblleft", "textquotedblright",
+		"textendash", "textemdash", "textellipsis", "ldots", "dots", "textexclamdown", "textquestiondown",
+		"guillemotleft", "guillemotright", "textbackslash", "textasciitilde", "textasciicircum", "textbar",
+		"textless", "textgreater", "textbullet", "textdagger", "textsection", "textparagraph",
+		"textcopyright", "textregistered", "texttrademark":
+		dp.addWord(textSymbols[cmd.Name], style)
+
+	case " ":
+		if dp.lineHasContent {
+			dp.addSpace(style)
+		}
+
+	case "@", "-", "/":
+		// Space factors, discretionary hyphens and italic corrections have
+		// no effect here
+
+	case "vfil":
+		dp.addStretch(1, fil)
+
+	case "vfill":
+		dp.addStretch(1, fill)
+
+	case "enlargethispage":
+		if len(cmd.Args) > 0 {
+			amount, err := dp.parseDimension(dp.extractRawArgument(cmd.Args[0]))
+			if err != nil {
+				dp.warn("\\%s: %v", cmd.Name, err)
+				break
+			}
+			dp.enlargePage(amount)
+		}
+
+	case "thepage":
+		dp.addText(dp.formatPageNumber(dp.page.number), style)
+
+	case "tableofcontents":
+		dp.addTableOfContents()
+
+	case "verb", "verb*":
+		if len(cmd.Args) > 0 {
+			if verb, ok := cmd.Args[0].(*parser.VerbatimNode); ok {
+				dp.addVerbatimText(verb.Value, cmd.Name == "verb*")
+			}
+		}
+
+	case "lstinputlisting", "inputminted":
+		dp.processInputListing(cmd)
+
+	case "DeclareMathOperator", "DeclareMathOperator*":
+		dp.declareMathOperator(cmd)
+
 	case "item":
 		dp.addListItem()
 		// Process the content that follows the \item command
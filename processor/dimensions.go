@@ -0,0 +1,153 @@
+package processor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// unitPoints is the size of each TeX unit in PDF points (big points)
+var unitPoints = map[string]float64{
+	"pt": 72 / 72.27,
+	"bp": 1,
+	"pc": 12 * 72 / 72.27,
+	"in": 72,
+	"cm": 72 / 2.54,
+	"mm": 72 / 25.4,
+	"dd": 1238.0 / 1157 * 72 / 72.27,
+	"cc": 12 * 1238.0 / 1157 * 72 / 72.27,
+	"sp": 72 / 72.27 / 65536,
+}
+
+// parseDimension converts a TeX dimension such as "2cm", "-1.5em" or
+// "2\baselineskip" to points. Font-relative units use the current font size.
+func (dp *DocumentProcessor) parseDimension(raw string) (float64, error) {
+	s := strings.Join(strings.Fields(raw), "")
+	if s == "" {
+		return 0, fmt.Errorf("missing dimension")
+	}
+
+	// The factor is everything before the unit; a bare unit means 1
+	end := 0
+	for end < len(s) && strings.ContainsRune("+-.,0123456789", rune(s[end])) {
+		end++
+	}
+	number, unit := strings.ReplaceAll(s[:end], ",", "."), s[end:]
+	factor := 1.0
+	switch number {
+	case "", "+":
+	case "-":
+		factor = -1
+	default:
+		f, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid dimension %q", raw)
+		}
+		factor = f
+	}
+
+	if points, ok := unitPoints[unit]; ok {
+		return factor * points, nil
+	}
+	switch unit {
+	case "em":
+		return factor * dp.fontSize, nil
+	case "ex":
+		return factor * dp.fontSize * 0.5, nil
+	case "\\baselineskip":
+		return factor * dp.lineHeight, nil
+	case "\\textwidth":
+		return factor * dp.textWidth, nil
+	case "\\linewidth", "\\columnwidth":
+		return factor * dp.generator.GetContentWidth(), nil
+	case "\\textheight":
+		return factor * dp.generator.GetContentHeight(), nil
+	}
+	return 0, fmt.Errorf("unknown unit in dimension %q", raw)
+}
+
+// Orders of infinite stretch; glue of a higher order takes all the space
+// from glue of lower orders
+const (
+	finite = iota
+	fil
+	fill
+	filll
+)
+
+// glue is TeX glue: a natural size that may stretch and shrink. Stretch and
+// shrink of order fil and up are infinite.
+type glue struct {
+	natural                   float64
+	stretch, shrink           float64
+	stretchOrder, shrinkOrder int
+}
+
+// parseGlue converts TeX glue such as "1cm plus 2fill minus 3pt", "\fill"
+// or "\stretch{2}" to points
+func (dp *DocumentProcessor) parseGlue(raw string) (glue, error) {
+	s := strings.TrimSpace(raw)
+	switch {
+	case s == "\\fill":
+		return glue{stretch: 1, stretchOrder: fill}, nil
+	case strings.HasPrefix(s, "\\stretch"):
+		factor := strings.Trim(strings.TrimPrefix(s, "\\stretch"), "{} ")
+		f, err := strconv.ParseFloat(factor, 64)
+		if err != nil {
+			return glue{}, fmt.Errorf("invalid stretch factor %q", factor)
+		}
+		return glue{stretch: f, stretchOrder: fill}, nil
+	}
+
+	natural, rest := s, ""
+	if i := strings.Index(s, "plus"); i >= 0 {
+		natural, rest = s[:i], s[i:]
+	} else if i := strings.Index(s, "minus"); i >= 0 {
+		natural, rest = s[:i], s[i:]
+	}
+
+	var g glue
+	var err error
+	if g.natural, err = dp.parseDimension(natural); err != nil {
+		return glue{}, err
+	}
+
+	stretch, shrink := "", ""
+	if rest, ok := strings.CutPrefix(rest, "plus"); ok {
+		stretch, shrink, _ = strings.Cut(rest, "minus")
+	} else {
+		shrink = strings.TrimPrefix(rest, "minus")
+	}
+	if stretch != "" {
+		if g.stretch, g.stretchOrder, err = dp.parseStretch(stretch); err != nil {
+			return glue{}, err
+		}
+	}
+	if shrink != "" {
+		if g.shrink, g.shrinkOrder, err = dp.parseStretch(shrink); err != nil {
+			return glue{}, err
+		}
+	}
+	return g, nil
+}
+
+// parseStretch reads the stretch or shrink of glue, a dimension or an
+// amount of fil, fill or filll
+func (dp *DocumentProcessor) parseStretch(raw string) (float64, int, error) {
+	s := strings.Join(strings.Fields(raw), "")
+	for order, unit := range []string{"filll", "fill", "fil"} {
+		if number, ok := strings.CutSuffix(s, unit); ok {
+			factor := 1.0
+			if number != "" && number != "+" {
+				f, err := strconv.ParseFloat(strings.ReplaceAll(number, ",", "."), 64)
+				if err != nil {
+					return 0, 0, fmt.Errorf("invalid stretch %q", raw)
+				}
+				factor = f
+			}
+			return factor, filll - order, nil
+		}
+	}
+	points, err := dp.parseDimension(s)
+	return points, finite, err
+}
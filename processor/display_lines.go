@@ -0,0 +1,252 @@
+package processor
+
+import (
+	"strings"
+
+	"github.com/rickykimani/gotex/parser"
+)
+
+// jot is the space added between the lines of align, gather and multline,
+// in em
+const jot = 0.25
+
+// equationNumbering is how an equation or a line of align is numbered: its
+// \label, its \tag, and whether \nonumber or \notag leaves it unnumbered
+type equationNumbering struct {
+	label    string
+	tag      string
+	tagStar  bool // \tag* sets the tag without parentheses
+	nonumber bool
+}
+
+// takeNumbering records a \label, \tag, \nonumber or \notag command in
+// numbering, reporting whether the node was one
+func (dp *DocumentProcessor) takeNumbering(node parser.Node, numbering *equationNumbering) bool {
+	cmd, ok := node.(*parser.Command)
+	if !ok {
+		return false
+	}
+	switch cmd.Name {
+	case "label":
+		if len(cmd.Args) > 0 {
+			numbering.label = strings.TrimSpace(dp.extractText(cmd.Args[0]))
+		}
+	case "tag", "tag*":
+		if len(cmd.Args) > 0 {
+			numbering.tag = strings.TrimSpace(dp.extractText(cmd.Args[0]))
+			numbering.tagStar = cmd.Name == "tag*"
+		}
+	case "nonumber", "notag":
+		numbering.nonumber = true
+	default:
+		return false
+	}
+	return true
+}
+
+// number returns the number of an equation: its tag, none after
+// \nonumber, or otherwise the next number of the counter
+func (n equationNumbering) number(section int, counter *int) string {
+	switch {
+	case n.tag != "":
+		return n.tag
+	case n.nonumber:
+		return ""
+	}
+	*counter++
+	return formatEquationNumber(section, *counter)
+}
+
+// display returns a number as it is set beside its equation, which is in
+// parentheses unless it comes from \tag*
+func (n equationNumbering) display(number string) string {
+	if number == "" || n.tagStar {
+		return number
+	}
+	return "(" + number + ")"
+}
+
+// displayLine is a line of an align, gather or multline environment: the
+// source of its cells, any \intertext before it and how it is numbered
+type displayLine struct {
+	cells     []string
+	intertext []parser.Node
+	equationNumbering
+}
+
+// hasMath reports whether a line has any math in its cells, which a line
+// of only \intertext has not
+func (l displayLine) hasMath() bool {
+	for _, cell := range l.cells {
+		if strings.TrimSpace(cell) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// displayLines splits the body of an align, gather or multline environment
+// into lines at \\ and cells at &, taking out the commands that number
+// each line. The lines of starred environments are not numbered, and
+// multline is numbered once, on its last line.
+func (dp *DocumentProcessor) displayLines(env *parser.Environment) []displayLine {
+	var lines []displayLine
+	var cells [][]parser.Node
+	line := displayLine{}
+
+	endLine := func() {
+		for _, cell := range cells {
+			line.cells = append(line.cells, dp.extractRawTextFromNodes(cell))
+		}
+		lines = append(lines, line)
+		cells, line = [][]parser.Node{nil}, displayLine{}
+	}
+	add := func(node parser.Node) {
+		cells[len(cells)-1] = append(cells[len(cells)-1], node)
+	}
+
+	cells = [][]parser.Node{nil}
+	for _, node := range env.Body {
+		if dp.takeNumbering(node, &line.equationNumbering) {
+			continue
+		}
+		switch n := node.(type) {
+		case *parser.Command:
+			switch n.Name {
+			case "\\", "\\*":
+				endLine()
+				continue
+			case "intertext":
+				if len(n.Args) > 0 {
+					line.intertext = argumentNodes(n.Args[0])
+				}
+				continue
+			}
+		case *parser.TextNode:
+			for i, part := range strings.Split(n.Value, "&") {
+				if i > 0 {
+					cells = append(cells, nil)
+				}
+				add(&parser.TextNode{Value: part, Position: n.Position})
+			}
+			continue
+		}
+		add(node)
+	}
+	endLine()
+
+	// A \\ after the last line does not start another
+	if last := lines[len(lines)-1]; len(lines) > 1 && !last.hasMath() && last.intertext == nil &&
+		last.label == "" && last.tag == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	if strings.HasSuffix(env.Name, "*") {
+		for i := range lines {
+			if lines[i].tag == "" {
+				lines[i].nonumber = true
+			}
+		}
+	}
+	if strings.TrimSuffix(env.Name, "*") == "multline" {
+		numberLastLine(lines)
+	}
+	return lines
+}
+
+// numberLastLine moves the numbering of all lines to the last, as multline
+// is one equation
+func numberLastLine(lines []displayLine) {
+	var numbering equationNumbering
+	for i := range lines {
+		n := lines[i].equationNumbering
+		if n.label != "" {
+			numbering.label = n.label
+		}
+		if n.tag != "" {
+			numbering.tag, numbering.tagStar = n.tag, n.tagStar
+		}
+		numbering.nonumber = numbering.nonumber || n.nonumber
+		lines[i].equationNumbering = equationNumbering{nonumber: true}
+	}
+	lines[len(lines)-1].equationNumbering = numbering
+}
+
+// argumentNodes returns the nodes of a command argument
+func argumentNodes(arg parser.Node) []parser.Node {
+	if group, ok := arg.(*parser.Group); ok {
+		return group.Nodes
+	}
+	return []parser.Node{arg}
+}
+
+// processDisplayLines sets an align, gather or multline environment: each
+// line is display math with its number at the right margin, and the
+// columns of align line up from one line to the next, across any
+// \intertext between them
+func (dp *DocumentProcessor) processDisplayLines(env *parser.Environment, style string) {
+	lines := dp.displayLines(env)
+	name := strings.TrimSuffix(env.Name, "*")
+
+	array := &parser.MathArray{Name: name, Position: env.Position}
+	for _, line := range lines {
+		if !line.hasMath() {
+			continue
+		}
+		// gather and multline have one column, so & does nothing
+		cells := line.cells
+		if name != "align" {
+			cells = []string{strings.Join(cells, "")}
+		}
+		var row []parser.Node
+		for _, cell := range cells {
+			content := dp.parseMathContent(cell, false).Content
+			row = append(row, &parser.Group{Nodes: content, Position: env.Position})
+		}
+		array.Rows = append(array.Rows, row)
+	}
+	rows := dp.mathProcessor.MeasureDisplayRows(array)
+
+	// Lines after the first follow at least a line apart, and further if
+	// they are tall
+	inDisplay := false
+	depth, row := 0.0, 0
+	for _, line := range lines {
+		if line.intertext != nil {
+			if inDisplay {
+				dp.endDisplay(depth)
+				inDisplay = false
+			}
+			dp.processNodes(line.intertext, style)
+		}
+		if !line.hasMath() {
+			continue
+		}
+
+		height, lineDepth := rows.Extent(row)
+		if inDisplay {
+			dp.newLine()
+			dp.addVerticalSpace(jot*dp.fontSize + max(depth+height-dp.lineHeight, 0))
+		} else {
+			dp.startDisplay(height)
+			inDisplay = true
+		}
+
+		number := line.number(dp.sectionCounter, &dp.equationCounter)
+		if number != "" {
+			dp.addTarget("equation." + number)
+		}
+		text := line.display(number)
+		reserve := 0.0
+		if text != "" {
+			reserve = dp.generator.GetTextWidth(text, dp.fontSize, "normal")
+		}
+		dp.mathProcessor.RenderDisplayRow(rows, row, dp.currentY, reserve)
+		dp.addEquationNumber(text)
+		depth = lineDepth
+		row++
+	}
+	if inDisplay {
+		dp.endDisplay(depth)
+	}
+}
@@ -0,0 +1,131 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rickykimani/gotex/parser"
+	"github.com/rickykimani/gotex/pdf"
+)
+
+// pageFloat is a float laid out apart from the text, waiting for the top of
+// a page after the one it appears on
+type pageFloat struct {
+	recording *pdf.Recording
+	height    float64 // from the first baseline to the last
+	position  int     // the galley box it appears at, counted from the start
+}
+
+// processFloat handles the figure and table environments. Starred ones in
+// two-column text span both columns and, as in LaTeX, go to the top of the
+// next page. The others stay where they appear, kept on one page.
+func (dp *DocumentProcessor) processFloat(env *parser.Environment, style string) {
+	kind := strings.TrimSuffix(env.Name, "*")
+	if kind != env.Name && dp.columns > 1 {
+		dp.addSpanningFloat(kind, env.Body, style)
+		return
+	}
+
+	if dp.lineHasContent {
+		dp.newLine()
+	}
+	dp.addVerticalSpace(dp.lineHeight * 0.5)
+
+	first := dp.boxesShipped + len(dp.galley)
+	dp.keepTogether++
+	dp.layoutFloat(kind, env.Body, style)
+	dp.keepTogether--
+	if dp.boxesShipped+len(dp.galley) > first {
+		// The float may break from what follows it
+		dp.galley[len(dp.galley)-1].penalty = 0
+	}
+
+	dp.addVerticalSpace(dp.lineHeight * 0.5)
+}
+
+// addSpanningFloat lays a float out across the full text width and queues
+// it for the page builder. The text around it carries on as if it were not
+// there.
+func (dp *DocumentProcessor) addSpanningFloat(kind string, body []parser.Node, style string) {
+	y, x, lineHasContent := dp.currentY, dp.currentLineX, dp.lineHasContent
+	paragraph, paragraphLine := dp.paragraph, dp.paragraphLine
+	marginRight := dp.generator.MarginRight
+
+	dp.inFloat = true
+	dp.generator.MarginRight = dp.generator.PageWidth - dp.generator.MarginLeft - dp.textWidth
+	dp.currentY, dp.currentLineX, dp.lineHasContent = 0, dp.generator.MarginLeft, false
+
+	recording := dp.generator.Record(func() {
+		dp.layoutFloat(kind, body, style)
+	})
+	height := max(-(dp.currentY + dp.lineHeight), 0)
+
+	dp.inFloat = false
+	dp.generator.MarginRight = marginRight
+	dp.currentY, dp.currentLineX, dp.lineHasContent = y, x, lineHasContent
+	dp.paragraph, dp.paragraphLine = paragraph, paragraphLine
+
+	dp.floats = append(dp.floats, &pageFloat{
+		recording: recording,
+		height:    height,
+		position:  dp.boxesShipped + len(dp.galley),
+	})
+}
+
+// layoutFloat numbers a float and draws its body
+func (dp *DocumentProcessor) layoutFloat(kind string, body []parser.Node, style string) {
+	switch kind {
+	case "figure":
+		dp.figureCounter++
+		dp.addTarget(fmt.Sprintf("figure.%d", dp.figureCounter))
+	case "table":
+		dp.tableCounter++
+		dp.addTarget(fmt.Sprintf("table.%d", dp.tableCounter))
+	}
+
+	outer := dp.floatKind
+	dp.floatKind = kind
+	dp.processNodes(body, style)
+	if dp.lineHasContent {
+		dp.newLine()
+	}
+	dp.floatKind = outer
+}
+
+// addCaption draws a float's \caption with its number, centered when it
+// fits on one line
+func (dp *DocumentProcessor) addCaption(cmd *parser.Command, style string) {
+	if len(cmd.Args) == 0 {
+		return
+	}
+
+	var label string
+	switch dp.floatKind {
+	case "figure":
+		label = fmt.Sprintf("Figure %d: ", dp.figureCounter)
+	case "table":
+		label = fmt.Sprintf("Table %d: ", dp.tableCounter)
+	default:
+		dp.warn("\\caption outside a figure or table")
+		return
+	}
+
+	if dp.lineHasContent {
+		dp.newLine()
+	}
+	dp.addVerticalSpace(dp.lineHeight * 0.3)
+
+	text := label + strings.TrimSpace(dp.extractTypesetText(cmd.Args[0]))
+	if dp.calculateTextWidth(text, style) <= dp.generator.GetContentWidth() {
+		dp.generator.AddTextWithAlignment(text, dp.currentLineX, dp.currentY, dp.fontSize, style, "center")
+		dp.newLine()
+		return
+	}
+
+	dp.addText(strings.TrimSpace(label), style)
+	dp.addSpace(style)
+	dp.processStyledText(cmd.Args[0], style)
+	if dp.lineHasContent {
+		dp.newLine()
+	}
+}
@@ -0,0 +1,185 @@
+package processor
+
+import (
+	"strings"
+	"time"
+
+	"github.com/rickykimani/gotex/parser"
+	"github.com/rickykimani/gotex/pdf"
+)
+
+// linkTarget is where a link goes: an external URL or an anchor in the document
+type linkTarget struct {
+	url    string
+	anchor string
+}
+
+// hyperrefOptions holds the \hypersetup keys GoTeX understands
+type hyperrefOptions struct {
+	title, author, subject string
+	keywords               []string
+
+	// With colorlinks, link text is drawn in these colors
+	colorLinks                     bool
+	linkColor, citeColor, urlColor pdf.Color
+}
+
+// defaultHyperrefOptions uses hyperref's default link colors
+func defaultHyperrefOptions() hyperrefOptions {
+	return hyperrefOptions{
+		linkColor: namedColors["red"],
+		citeColor: namedColors["green"],
+		urlColor:  namedColors["magenta"],
+	}
+}
+
+// applyHyperrefOptions reads the key=value list of \hypersetup or
+// \usepackage[...]{hyperref}
+func (dp *DocumentProcessor) applyHyperrefOptions(raw string) {
+	for key, value := range parseKeyValues(raw) {
+		switch key {
+		case "pdftitle":
+			dp.hyperref.title = value
+		case "pdfauthor":
+			dp.hyperref.author = value
+		case "pdfsubject":
+			dp.hyperref.subject = value
+		case "pdfkeywords":
+			dp.hyperref.keywords = splitKeywords(value)
+		case "colorlinks":
+			dp.hyperref.colorLinks = value == "true"
+		case "linkcolor", "citecolor", "urlcolor":
+			color, err := dp.resolveColor("", value)
+			if err != nil {
+				dp.warn("\\hypersetup{%s}: %v", key, err)
+				continue
+			}
+			switch key {
+			case "linkcolor":
+				dp.hyperref.linkColor = color
+			case "citecolor":
+				dp.hyperref.citeColor = color
+			default:
+				dp.hyperref.urlColor = color
+			}
+		}
+	}
+}
+
+// processHyperrefCommand handles \href, \url and \hypersetup
+func (dp *DocumentProcessor) processHyperrefCommand(cmd *parser.Command, style string) {
+	switch cmd.Name {
+	case "hypersetup":
+		if len(cmd.Args) > 0 {
+			dp.applyHyperrefOptions(dp.extractRawArgument(cmd.Args[0]))
+		}
+
+	case "url":
+		if len(cmd.Args) > 0 {
+			url := dp.extractText(cmd.Args[0])
+			dp.withLink(linkTarget{url: url}, dp.hyperref.urlColor, func() {
+				dp.addVerbatimText(url, false)
+			})
+		}
+
+	case "href":
+		if len(cmd.Args) < 2 {
+			return
+		}
+		url := dp.extractText(cmd.Args[0])
+		dp.withLink(linkTarget{url: url}, dp.hyperref.urlColor, func() {
+			dp.processStyledText(cmd.Args[1], style)
+		})
+	}
+}
+
+// withLink makes every word drawn by draw part of a link to target, in color
+// when colorlinks is set
+func (dp *DocumentProcessor) withLink(target linkTarget, color pdf.Color, draw func()) {
+	depth := dp.colorDepth()
+	if dp.hyperref.colorLinks {
+		dp.pushColor(color)
+	}
+
+	previous := dp.link
+	dp.link = &target
+	draw()
+	dp.link = previous
+
+	dp.restoreColor(depth)
+}
+
+// addLinkedText draws text as a link to target
+func (dp *DocumentProcessor) addLinkedText(text, style string, target linkTarget, color pdf.Color) {
+	dp.withLink(target, color, func() {
+		dp.addText(text, style)
+	})
+}
+
+// markLink makes the word of the given width at x on the current line
+// clickable when a link is active
+func (dp *DocumentProcessor) markLink(x, width float64) {
+	if dp.link == nil {
+		return
+	}
+
+	bottom := dp.currentY - dp.fontSize*0.25
+	if dp.link.url != "" {
+		dp.generator.AddExternalLink(dp.link.url, x, bottom, width, dp.fontSize)
+	} else if dp.link.anchor != "" {
+		dp.generator.AddInternalLink(dp.link.anchor, x, bottom, width, dp.fontSize)
+	}
+}
+
+// dateLayouts are the \date formats recognised as the creation date
+var dateLayouts = []string{
+	"January 2, 2006",
+	"2 January 2006",
+	"January 2006",
+	"2006-01-02",
+	"2006/01/02",
+}
+
+// setMetadata fills the PDF document information from \hypersetup, falling
+// back to \title, \author and \date. A \date that is not a recognisable
+// date, and a missing one, leave the build time as the creation date.
+func (dp *DocumentProcessor) setMetadata() {
+	created := dp.buildTime
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(dp.date), dp.buildTime.Location()); err == nil {
+			created = t
+			break
+		}
+	}
+
+	dp.generator.SetMetadata(pdf.Metadata{
+		Title:        firstNonEmpty(dp.hyperref.title, dp.title),
+		Author:       firstNonEmpty(dp.hyperref.author, dp.author),
+		Subject:      dp.hyperref.subject,
+		Keywords:     dp.hyperref.keywords,
+		Creator:      "GoTeX",
+		Producer:     "GoTeX",
+		CreationDate: created,
+		ModDate:      dp.buildTime,
+	})
+}
+
+// splitKeywords splits a comma separated keyword list
+func splitKeywords(list string) []string {
+	var keywords []string
+	for _, keyword := range strings.Split(list, ",") {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+	return keywords
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
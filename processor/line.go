@@ -1,12 +1,42 @@
 package processor
 
+import "github.com/rickykimani/gotex/parser"
+
 // newLine moves to the next line with consistent spacing
 func (dp *DocumentProcessor) newLine() {
+	dp.closeBox()
 	dp.currentY -= dp.lineHeight
-	dp.checkNewPage()
 	dp.lineHasContent = false
-	dp.currentLineX = dp.generator.MarginLeft
+	dp.currentLineX = dp.generator.MarginLeft + dp.hangIndent
 	if dp.listLevel > 0 {
 		dp.currentLineX += float64(dp.listLevel * 15)
 	}
+	dp.lineBroken = false
+
+	// \vspace in a paragraph takes effect after the line it is on
+	skips := dp.lineSkips
+	dp.lineSkips = nil
+	for _, skip := range skips {
+		dp.addVerticalGlue(skip.glue, skip.keep)
+	}
+}
+
+// breakLine ends the current line for \\ and \newline, leaving the space
+// given in the optional argument below it. \\* also keeps the page from
+// breaking after the line.
+func (dp *DocumentProcessor) breakLine(cmd *parser.Command) {
+	if cmd.Name == "\\*" {
+		dp.addPenalty(noBreak)
+	}
+	dp.newLine()
+	dp.lineEnded = true
+
+	if len(cmd.Optional) > 0 {
+		g, err := dp.parseGlue(dp.extractRawArgument(cmd.Optional[0]))
+		if err != nil {
+			dp.warn("\\%s: %v", cmd.Name, err)
+			return
+		}
+		dp.addVerticalGlue(g, false)
+	}
 }
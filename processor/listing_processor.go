@@ -0,0 +1,178 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rickykimani/gotex/highlight"
+	"github.com/rickykimani/gotex/parser"
+	"github.com/rickykimani/gotex/pdf"
+)
+
+// listingOptions holds the lstlisting and minted keys GoTeX understands
+type listingOptions struct {
+	language  string
+	numbers   bool
+	firstLine int
+	lastLine  int // 0 means the last line of the source
+	caption   string
+	label     string
+}
+
+// listingColors maps token kinds to their colors in highlighted listings
+var listingColors = map[highlight.TokenKind]pdf.Color{
+	highlight.Plain:    pdf.Black,
+	highlight.Keyword:  {R: 0, G: 0, B: 170},
+	highlight.String:   {R: 163, G: 21, B: 21},
+	highlight.Comment:  {R: 90, G: 125, B: 90},
+	highlight.Number:   {R: 9, G: 128, B: 88},
+	highlight.Variable: {R: 128, G: 0, B: 128},
+}
+
+// parseListingOptions reads a key=value list from an optional argument.
+// Both listings keys (numbers=left) and minted keys (linenos) are accepted.
+func (dp *DocumentProcessor) parseListingOptions(optional []parser.Node) listingOptions {
+	opts := listingOptions{firstLine: 1}
+	if len(optional) == 0 {
+		return opts
+	}
+
+	for key, value := range parseKeyValues(dp.extractText(optional[0])) {
+		switch key {
+		case "language":
+			opts.language = value
+		case "numbers":
+			opts.numbers = value == "left"
+		case "linenos":
+			opts.numbers = value == "true"
+		case "firstline":
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				opts.firstLine = n
+			}
+		case "lastline":
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				opts.lastLine = n
+			}
+		case "caption", "title":
+			opts.caption = value
+		case "label":
+			opts.label = value
+		}
+	}
+	return opts
+}
+
+// processListingEnvironment renders lstlisting and minted environments
+func (dp *DocumentProcessor) processListingEnvironment(env *parser.Environment) {
+	opts := dp.parseListingOptions(env.Optional)
+	if env.Name == "minted" && len(env.Args) > 0 {
+		opts.language = dp.extractText(env.Args[0])
+	}
+
+	var source strings.Builder
+	for _, node := range env.Body {
+		if v, ok := node.(*parser.VerbatimNode); ok {
+			source.WriteString(v.Value)
+		}
+	}
+
+	dp.addListing(source.String(), opts)
+}
+
+// processInputListing renders \lstinputlisting[opts]{file} and
+// \inputminted[opts]{language}{file}
+func (dp *DocumentProcessor) processInputListing(cmd *parser.Command) {
+	opts := dp.parseListingOptions(cmd.Optional)
+	args := cmd.Args
+	if cmd.Name == "inputminted" {
+		if len(args) < 2 {
+			return
+		}
+		opts.language = dp.extractText(args[0])
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return
+	}
+
+	filename := strings.TrimSpace(dp.extractText(args[0]))
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(dp.baseDir, filename)
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		dp.warn("\\%s: %v", cmd.Name, err)
+		return
+	}
+
+	source := strings.TrimSuffix(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	dp.addListing(source, opts)
+}
+
+// addListing renders source code in the monospace face, colored by token
+// kind when the language is known, with optional caption and line numbers
+func (dp *DocumentProcessor) addListing(source string, opts listingOptions) {
+	sourceLines := strings.Split(source, "\n")
+	for i, line := range sourceLines {
+		sourceLines[i] = expandTabs(strings.TrimRight(line, "\r"))
+	}
+
+	var lang *highlight.Language
+	if opts.language != "" {
+		var ok bool
+		if lang, ok = highlight.LookupLanguage(opts.language); !ok {
+			dp.warn("no syntax highlighting for language %q", opts.language)
+		}
+	}
+	lines := highlight.Tokenize(strings.Join(sourceLines, "\n"), lang)
+
+	first := min(opts.firstLine, len(lines))
+	last := len(lines)
+	if opts.lastLine > 0 {
+		last = min(opts.lastLine, len(lines))
+	}
+
+	if dp.lineHasContent {
+		dp.newLine()
+	}
+
+	if opts.caption != "" {
+		dp.listingCounter++
+		dp.addTarget(fmt.Sprintf("listing.%d", dp.listingCounter))
+		caption := fmt.Sprintf("Listing %d: %s", dp.listingCounter, opts.caption)
+		dp.generator.AddTextWithAlignment(caption, dp.currentLineX, dp.currentY, dp.fontSize, "normal", "center")
+		dp.newLine()
+	}
+	dp.addVerticalSpace(dp.lineHeight * 0.3)
+
+	previousColor := dp.generator.Color()
+	numberSize := dp.fontSize * 0.7
+
+	for number := first; number <= last; number++ {
+		x := dp.currentLineX
+
+		if opts.numbers {
+			dp.generator.SetColor(previousColor)
+			label := strconv.Itoa(number)
+			labelWidth := dp.generator.GetTextWidth(label, numberSize, "mono")
+			dp.generator.AddText(label, x-labelWidth-8, dp.currentY, numberSize, "mono")
+		}
+
+		for _, tok := range lines[number-1] {
+			if color, ok := listingColors[tok.Kind]; ok && tok.Kind != highlight.Plain {
+				dp.generator.SetColor(color)
+			} else {
+				dp.generator.SetColor(previousColor)
+			}
+			dp.generator.AddText(tok.Text, x, dp.currentY, dp.fontSize, "mono")
+			x += dp.calculateTextWidth(tok.Text, "mono")
+		}
+		dp.newLine()
+	}
+
+	dp.generator.SetColor(previousColor)
+}
@@ -1,8 +1,17 @@
 package processor
 
-import "github.com/rickykimani/gotex/parser"
+import (
+	"strings"
+
+	"github.com/rickykimani/gotex/parser"
+)
 
 func (dp *DocumentProcessor) processMathNode(mathNode *parser.MathNode, _ string) {
+	if !mathNode.Inline {
+		dp.addDisplayMath(mathNode, "")
+		return
+	}
+
 	// Process math content using the math processor
 	mathWidth := dp.mathProcessor.ProcessMathNode(mathNode, dp.currentLineX, dp.currentY)
 
@@ -15,3 +24,126 @@ func (dp *DocumentProcessor) processMathNode(mathNode *parser.MathNode, _ string
 		dp.currentLineX += 3.0 // Small space after math
 	}
 }
+
+// addDisplayMath sets display math centered on a line of its own, with the
+// equation number, if any, at the right margin
+func (dp *DocumentProcessor) addDisplayMath(mathNode *parser.MathNode, number string) {
+	height, depth := dp.mathProcessor.MathExtent(mathNode)
+	dp.startDisplay(height)
+
+	// Display math centers itself between the margins
+	dp.mathProcessor.ProcessMathNode(mathNode, dp.currentLineX, dp.currentY)
+	dp.addEquationNumber(number)
+	dp.endDisplay(depth)
+}
+
+// startDisplay leaves the space above display math that reaches height
+// above its baseline, and moves to that baseline
+func (dp *DocumentProcessor) startDisplay(height float64) {
+	if dp.lineHasContent {
+		dp.newLine()
+	}
+
+	// Math taller than a line, such as a matrix, pushes the lines around
+	// it apart
+	dp.addVerticalSpace(dp.lineHeight*0.5 + max(height-dp.lineHeight, 0))
+}
+
+// endDisplay ends display math that reaches depth below its baseline and
+// leaves the space below it
+func (dp *DocumentProcessor) endDisplay(depth float64) {
+	dp.newLine() // Ensure we're on a new line after the equation
+	dp.addVerticalSpace(dp.lineHeight*0.5 + max(depth-dp.lineHeight*0.75, 0))
+	dp.lineEnded = true
+}
+
+// addEquationNumber sets an equation number, if any, at the right margin
+// of the current line
+func (dp *DocumentProcessor) addEquationNumber(number string) {
+	if number == "" {
+		return
+	}
+	numberWidth := dp.generator.GetTextWidth(number, dp.fontSize, "normal")
+	numberX := dp.generator.MarginLeft + dp.generator.GetContentWidth() - numberWidth
+	dp.generator.AddText(number, numberX, dp.currentY, dp.fontSize, "normal")
+}
+
+// declareMathOperator handles \DeclareMathOperator{\name}{text}, which adds
+// an operator name for the math after it. The starred form puts limits
+// under the name in display style.
+func (dp *DocumentProcessor) declareMathOperator(cmd *parser.Command) {
+	if len(cmd.Args) < 2 {
+		return
+	}
+	name := strings.TrimPrefix(strings.TrimSpace(dp.extractRawArgument(cmd.Args[0])), "\\")
+	if name == "" {
+		dp.warn("\\%s: missing operator command", cmd.Name)
+		return
+	}
+	body := dp.parseMathContent(dp.extractRawArgument(cmd.Args[1]), true)
+	dp.mathProcessor.DeclareOperator(name, body.Content, cmd.Name == "DeclareMathOperator*")
+}
+
+// setMathText sets the content of \text and similar commands in math at a
+// size, drawing it at x and y if draw is set, and returns its width. The
+// content is text as in a paragraph: ligatures are applied, text commands
+// change its style and the math processor sets the math in it.
+func (dp *DocumentProcessor) setMathText(nodes []parser.Node, style string, x, y, size float64, draw bool) float64 {
+	start := x
+	add := func(text, style string) {
+		if draw {
+			dp.generator.AddText(text, x, y, size, style)
+		}
+		x += dp.generator.GetTextWidth(text, size, style)
+	}
+
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *parser.TextNode:
+			// Ties and line ends are spaces here, as the text is not broken
+			text := strings.Map(func(r rune) rune {
+				if r == '~' || r == '\n' {
+					return ' '
+				}
+				return r
+			}, n.Value)
+			add(applyLigatures(text, style), style)
+
+		case *parser.MathNode:
+			x += dp.mathProcessor.NestedMath(n, x, y, draw)
+
+		case *parser.Group:
+			x += dp.setMathText(n.Nodes, style, x, y, size, draw)
+
+		case *parser.Command:
+			argStyle := style
+			switch n.Name {
+			case "textbf":
+				argStyle = "bold"
+				if style == "italic" {
+					argStyle = "bold-italic"
+				}
+			case "textit":
+				argStyle = "italic"
+				if style == "bold" {
+					argStyle = "bold-italic"
+				}
+			case "emph":
+				argStyle = emphasize(style)
+			case "texttt":
+				argStyle = "mono"
+			default:
+				if symbol, ok := textSymbols[n.Name]; ok {
+					add(symbol, style)
+				} else if space, ok := textSpaces[n.Name]; ok {
+					x += space.em * size
+				}
+			}
+			for _, arg := range n.Args {
+				x += dp.setMathText([]parser.Node{arg}, argStyle, x, y, size, draw)
+			}
+		}
+	}
+
+	return x - start
+}
@@ -0,0 +1,39 @@
+package processor
+
+import (
+	gomath "math"
+	"testing"
+
+	"github.com/rickykimani/gotex/lexer"
+	"github.com/rickykimani/gotex/parser"
+)
+
+func TestMathTextContent(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string // The same math with the text set flat
+	}{
+		// Math in the text is math italic, as it is outside it
+		{`$\text{if $n$ ok}$`, `$\text{if }n\text{ ok}$`},
+		{`$x_{\text{if $n$}}$`, `$x_{\text{if }n}$`},
+		{`$\textbf{if $n$}$`, `$\textbf{if }n$`},
+		// Commands in the text print what they stand for, not their names
+		{`$\text{a \ldots{} b -- \textbf{c}}$`, `$\text{a … b – }\textbf{c}$`},
+		{`$\text{it's \emph{so}}$`, `$\text{it’s }\textit{so}$`},
+	}
+
+	set := func(source string) func(dp *DocumentProcessor) {
+		return func(dp *DocumentProcessor) {
+			doc, _ := parser.NewParser(lexer.NewLexer(source).Tokenize()).Parse()
+			dp.processNodes(doc.Body, "normal")
+		}
+	}
+
+	for _, tt := range tests {
+		got := lineWidth(t, "", set(tt.input))
+		expected := lineWidth(t, "", set(tt.expected))
+		if gomath.Abs(got-expected) > 0.01 {
+			t.Errorf("%s: expected the width of %s, %.2fpt, got %.2fpt", tt.input, tt.expected, expected, got)
+		}
+	}
+}
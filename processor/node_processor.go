@@ -7,10 +7,26 @@ import (
 )
 
 func (dp *DocumentProcessor) processNode(node parser.Node, style string) {
+	lineEnded := dp.lineEnded
+	dp.lineEnded = false
+
 	switch n := node.(type) {
 	case *parser.TextNode:
 		// Handle single newline: line break
 		if n.Value == "\n" {
+			// The line was already ended by \\
+			if lineEnded {
+				return
+			}
+
+			// A newline on an empty line is a blank line, which ends the
+			// paragraph; floats have no paragraphs and take no blank lines
+			if !dp.lineHasContent {
+				if dp.floatKind != "" {
+					return
+				}
+				dp.endParagraph()
+			}
 			dp.newLine()
 			dp.lastProcessedCommand = false
 			return
@@ -48,6 +64,10 @@ func (dp *DocumentProcessor) processNode(node parser.Node, style string) {
 		dp.processMathNode(n, style)
 		dp.lastProcessedCommand = false
 
+	case *parser.VerbatimNode:
+		dp.addVerbatimText(n.Value, false)
+		dp.lastProcessedCommand = false
+
 	case *parser.Group:
 		// Check if this is a styled text group (font command + text)
 		if len(n.Nodes) >= 2 {
@@ -67,10 +87,17 @@ func (dp *DocumentProcessor) processNode(node parser.Node, style string) {
 						if style == "bold" {
 							newStyle = "bold-italic"
 						}
+					case "emph":
+						newStyle = emphasize(style)
 					}
 				}
 				// Process the remaining nodes with the new style
+				depth := dp.colorDepth()
 				for i := 1; i < len(n.Nodes); i++ {
+					if declared, ok := dp.applyDeclaration(n.Nodes[i], newStyle); ok {
+						newStyle = declared
+						continue
+					}
 					if i > 1 {
 						// Add space between nodes if needed
 						prevNode := n.Nodes[i-1]
@@ -81,6 +108,7 @@ func (dp *DocumentProcessor) processNode(node parser.Node, style string) {
 					}
 					dp.processNode(n.Nodes[i], newStyle)
 				}
+				dp.restoreColor(depth)
 			} else {
 				// Regular group processing
 				dp.processNodes(n.Nodes, style)
@@ -94,7 +122,14 @@ func (dp *DocumentProcessor) processNode(node parser.Node, style string) {
 }
 
 func (dp *DocumentProcessor) processNodes(nodes []parser.Node, style string) {
+	defer dp.restoreColor(dp.colorDepth())
 	for i, node := range nodes {
+		// Declarations apply to the remaining nodes at this level
+		if newStyle, ok := dp.applyDeclaration(node, style); ok {
+			style = newStyle
+			continue
+		}
+
 		// Add space between nodes when appropriate
 		if i > 0 {
 			prevNode := nodes[i-1]
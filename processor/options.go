@@ -0,0 +1,47 @@
+package processor
+
+import "strings"
+
+// parseKeyValues splits a key=value list such as "language=Go, numbers=left"
+// into a map. Commas inside braces do not separate entries and one level of
+// braces around a value is removed. Keys without a value map to "true".
+func parseKeyValues(raw string) map[string]string {
+	values := make(map[string]string)
+
+	var entries []string
+	depth, start := 0, 0
+	for i, char := range raw {
+		switch char {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				entries = append(entries, raw[start:i])
+				start = i + 1
+			}
+		}
+	}
+	entries = append(entries, raw[start:])
+
+	for _, entry := range entries {
+		key, value, found := strings.Cut(entry, "=")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if !found {
+			values[key] = "true"
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, "{") && strings.HasSuffix(value, "}") {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+
+	return values
+}
@@ -1,9 +1,648 @@
 package processor
 
-func (dp *DocumentProcessor) checkNewPage() {
-	if dp.currentY < dp.generator.MarginBottom+50 {
+import gomath "math"
+
+const (
+	// Penalties at or beyond these values force or forbid a page break
+	forcedBreak = -10000
+	noBreak     = 10000
+
+	// sectionPenalty encourages breaking before a heading
+	sectionPenalty = -300
+
+	// Pages are ragged at the bottom: a page may come up this many lines
+	// short before it counts as badly underfull
+	raggedBottomLines = 3.0
+
+	// deplorable is the cost of a page that is too empty to be good but
+	// still better than an overfull one
+	deplorable = 100000
+
+	// columnSep is the space between columns, and floatSep the space below
+	// the floats at the top of a page
+	columnSep = 10.0
+	floatSep  = 20.0
+)
+
+// galleyBox is a run of drawing recorded at one vertical position, normally
+// a line of text, together with what follows it before the next box. The
+// page builder only breaks pages between boxes.
+type galleyBox struct {
+	start, end int     // recorded drawing operations
+	y          float64 // baseline, in the coordinates of an endless first page
+
+	// The paragraph the box belongs to and its line number in it, for club
+	// and widow penalties, and the penalty for breaking after a first line
+	paragraph, line int
+	club            int
+
+	// After the box: explicit penalties and the stretch of the glue, by
+	// order from fil to filll, where any glue of a higher order outstretches
+	// all glue of lower orders
+	penalty int
+	stretch [filll]float64
+
+	// Space above the box that stays when the box starts a page, as
+	// \vspace* does, and where its horizontal glue moves its drawing across
+	keepAbove float64
+	shifts    []shift
+
+	// \enlargethispage amount for the page the box lands on
+	enlarge float64
+
+	// The run of boxes set in the same columns the box belongs to, the
+	// number of columns, and whether a forced break after the box ends the
+	// page rather than the column, as \clearpage does
+	run, columns int
+	clear        bool
+}
+
+// closeBox ends the box drawn at the current position. It is called before
+// moving down, so boxes hold everything drawn at one height.
+func (dp *DocumentProcessor) closeBox() {
+	shifts := dp.lineShifts()
+
+	// Floats are recorded apart from the galley
+	if dp.inFloat {
+		return
+	}
+
+	// Operations that only note where something landed, such as marks and
+	// anchors, join the box drawn next rather than take a line of their own
+	end := dp.generator.Recorded()
+	if !dp.generator.Draws(dp.boxStart, end) {
+		return
+	}
+	dp.addBox(end, shifts)
+}
+
+// addBox adds the operations recorded since the last box up to end to the
+// galley as a box at the current position
+func (dp *DocumentProcessor) addBox(end int, shifts []shift) {
+	box := galleyBox{
+		start:     dp.boxStart,
+		end:       end,
+		y:         dp.currentY,
+		paragraph: dp.paragraph,
+		line:      dp.paragraphLine,
+		penalty:   dp.pendingPenalty,
+		enlarge:   dp.pendingEnlarge,
+		keepAbove: dp.pendingKeep,
+		shifts:    shifts,
+		run:       dp.run,
+		columns:   dp.columns,
+	}
+	if dp.keepTogether > 0 {
+		box.penalty = addPenalties(box.penalty, noBreak)
+	}
+	if box.line == 0 {
+		box.club = dp.penalties["club"]
+		if dp.afterHeading {
+			// The first line after a heading stays with the next one
+			box.club = noBreak
+			dp.afterHeading = false
+		}
+	}
+
+	dp.galley = append(dp.galley, box)
+	dp.boxStart = end
+	dp.paragraphLine++
+	dp.pendingPenalty = 0
+	dp.pendingEnlarge = 0
+	dp.pendingKeep = 0
+}
+
+// addPenalty discourages (positive) or encourages (negative) a page break
+// after the current line, or at the current position between lines
+func (dp *DocumentProcessor) addPenalty(penalty int) {
+	if dp.inFloat {
+		return
+	}
+	if dp.generator.Draws(dp.boxStart, dp.generator.Recorded()) {
+		dp.pendingPenalty = addPenalties(dp.pendingPenalty, penalty)
+		return
+	}
+	last := &dp.galley[len(dp.galley)-1]
+	last.penalty = addPenalties(last.penalty, penalty)
+}
+
+// addPenalties combines penalties; a forced break wins over a forbidden one
+func addPenalties(a, b int) int {
+	switch {
+	case a <= forcedBreak || b <= forcedBreak:
+		return forcedBreak
+	case a >= noBreak || b >= noBreak:
+		return noBreak
+	}
+	return a + b
+}
+
+// addStretch adds infinitely stretchable glue of the given order at the
+// current position, as \vfil and \vfill do. Glue like this on a page takes
+// up the space left at its bottom.
+func (dp *DocumentProcessor) addStretch(amount float64, order int) {
+	if dp.inFloat || order == finite {
+		return
+	}
+	dp.closeBox()
+	dp.galley[len(dp.galley)-1].stretch[order-1] += amount
+}
+
+// enlargePage makes the page the current position lands on taller by amount
+func (dp *DocumentProcessor) enlargePage(amount float64) {
+	dp.pendingEnlarge += amount
+}
+
+// endParagraph closes the current paragraph, which settles its widow
+// penalty, and lets the page builder ship the pages that are now complete
+func (dp *DocumentProcessor) endParagraph() {
+	dp.paragraph++
+	dp.paragraphLine = 0
+	dp.buildPages(false)
+}
+
+// breakPenalty is the penalty for breaking the page after box i, including
+// the club and widow penalties between lines of a paragraph
+func (dp *DocumentProcessor) breakPenalty(i int) int {
+	box, next := dp.galley[i], dp.galley[i+1]
+	penalty := box.penalty
+	if next.paragraph != box.paragraph {
+		return penalty
+	}
+
+	if box.line == 0 {
+		penalty = addPenalties(penalty, box.club)
+	}
+	lastLine := next.paragraph < dp.paragraph
+	if i+2 < len(dp.galley) {
+		lastLine = dp.galley[i+2].paragraph != next.paragraph
+	}
+	if lastLine {
+		penalty = addPenalties(penalty, dp.penalties["widow"])
+	}
+	return penalty
+}
+
+// buildPages ships every page whose content is settled. A page is complete
+// once a box no longer fits on it or a break is forced; it then ends at the
+// break with the lowest cost, which weighs the space left at the bottom
+// against the penalties. With final set, the rest becomes the last page.
+func (dp *DocumentProcessor) buildPages(final bool) {
+	if dp.inFloat {
+		return
+	}
+	for len(dp.galley) > 0 || (final && len(dp.floats) > 0) {
+		plan, used, complete := dp.planPage(final)
+		if !complete {
+			return
+		}
+		dp.shipPage(plan, used)
+	}
+}
+
+// placement puts a galley box on the page, moved by dx and dy
+type placement struct {
+	box    galleyBox
+	dx, dy float64
+}
+
+// placedFloat is a float at the top of the page with its first baseline at y
+type placedFloat struct {
+	float *pageFloat
+	y     float64
+}
+
+// pagePlan is what goes on a page: floats at the top, then galley boxes in
+// one or more runs of full-width text and columns
+type pagePlan struct {
+	floats     []placedFloat
+	placements []placement
+	bottom     float64 // the bottom of the text area, after \enlargethispage
+	lowest     float64 // the lowest baseline placed so far
+	forced     bool    // whether the page ends at a forced break
+}
+
+// planPage plans the next page from the start of the galley. It returns the
+// number of galley boxes used and whether the page is complete; an
+// incomplete page waits for more content.
+func (dp *DocumentProcessor) planPage(final bool) (pagePlan, int, bool) {
+	top := dp.generator.PageHeight - dp.generator.MarginTop
+	plan := pagePlan{bottom: dp.generator.MarginBottom, lowest: top}
+
+	// Floats from earlier pages go first, as many as fit
+	y := top
+	for _, f := range dp.floats {
+		if f.position >= dp.boxesShipped && len(dp.galley) > 0 {
+			break
+		}
+		if len(plan.floats) > 0 && y-f.height < plan.bottom {
+			break
+		}
+		plan.floats = append(plan.floats, placedFloat{float: f, y: y})
+		plan.lowest = y - f.height
+		y -= f.height + floatSep
+	}
+	if len(dp.galley) == 0 {
+		return plan, 0, len(plan.floats) > 0
+	}
+
+	// The first page keeps its position, later pages start at the top
+	dy := 0.0
+	if dp.pagesShipped > 0 || len(plan.floats) > 0 {
+		dy = y - dp.galley[0].keepAbove - dp.galley[0].y
+	}
+
+	// Runs of boxes set in the same number of columns are placed in turn. A
+	// run is closed once later boxes follow it, so it will not grow.
+	i := 0
+	for i < len(dp.galley) {
+		end := i
+		for end < len(dp.galley) && dp.galley[end].run == dp.galley[i].run {
+			end++
+		}
+		closed := final || end < len(dp.galley) || dp.galley[i].run < dp.run
+
+		var next int
+		var full bool
+		if dp.galley[i].columns > 1 {
+			next, dy, full = dp.planColumns(&plan, i, end, closed, dy)
+		} else {
+			next, full = dp.planFlow(&plan, i, end, closed, dy)
+		}
+		switch {
+		case full:
+			return plan, next, true
+		case next < end:
+			return plan, 0, false
+		}
+		i = next
+	}
+
+	plan.forced = final
+	return plan, i, final
+}
+
+// planFlow places the full-width boxes from i up to end. It returns where
+// the page ends when it fills up, or end when the run fits on it.
+func (dp *DocumentProcessor) planFlow(plan *pagePlan, i, end int, closed bool, dy float64) (int, bool) {
+	best, bestCost := -1, gomath.MaxInt
+	forced, overflow := false, false
+	stretchable := false
+
+	// Breaking before the run is an option when the page already has content
+	if i > 0 && dp.galley[i-1].end > dp.galley[0].start {
+		penalty := dp.breakPenalty(i - 1)
+		if penalty <= forcedBreak {
+			plan.forced = true
+			return i, true
+		}
+		if penalty < noBreak {
+			best, bestCost = i-1, dp.pageBadness(plan.lowest-plan.bottom, false)+penalty
+		}
+	}
+
+	for k := i; k < end; k++ {
+		box := dp.galley[k]
+		plan.bottom -= box.enlarge
+		if (k > 0 || len(plan.floats) > 0) && box.y+dy < plan.bottom {
+			overflow = true
+			break
+		}
+		if k+1 == len(dp.galley) {
+			break
+		}
+
+		// A page needs content before it can end
+		if box.end > dp.galley[0].start {
+			penalty := dp.breakPenalty(k)
+			if penalty <= forcedBreak {
+				best, forced = k, true
+				break
+			}
+			if penalty < noBreak {
+				cost := dp.pageBadness(box.y+dy-plan.bottom, stretchable) + penalty
+				if cost <= bestCost {
+					best, bestCost = k, cost
+				}
+			}
+		}
+		stretchable = stretchable || box.stretch != [filll]float64{}
+	}
+
+	switch {
+	case forced:
+	case overflow:
+		if best < 0 && i == 0 {
+			// Nowhere to break is allowed, so break as late as possible
+			for best+1 < end && dp.galley[best+1].y+dy >= plan.bottom {
+				best++
+			}
+			if len(plan.floats) == 0 {
+				best = max(best, 0)
+			}
+		} else if best < 0 {
+			best = i - 1
+		}
+	case closed:
+		dp.place(plan, i, end-1, 0, dy)
+		return end, false
+	default:
+		return i, false
+	}
+
+	dp.place(plan, i, best, 0, dy)
+	plan.forced = forced
+	return best + 1, true
+}
+
+// planColumns places the boxes from i up to end, a run set in columns. A
+// closed run that fits on the page is balanced, so that its columns come
+// out about equally long; otherwise the columns are filled one after the
+// other down to the bottom of the page. It returns where the page or the
+// run ends and the offset for the boxes that follow the run.
+func (dp *DocumentProcessor) planColumns(plan *pagePlan, i, end int, closed bool, dy float64) (int, float64, bool) {
+	n := dp.galley[i].columns
+	step := dp.columnWidth(n) + columnSep
+	colTop := dp.galley[i].y + dy
+	lowest := colTop
+
+	// The boxes after the run keep their distance to the lowest column
+	after := func() (int, float64, bool) {
+		dy := lowest - dp.galley[end-1].y
+		full := end < len(dp.galley) && dp.breakPenalty(end-1) <= forcedBreak
+		plan.forced = plan.forced || full
+		return end, dy, full
+	}
+
+	if closed {
+		if breaks, ok := dp.balanceColumns(i, end, n, colTop-plan.bottom); ok {
+			from := i
+			for c, last := range breaks {
+				if last < from {
+					continue
+				}
+				cdy := colTop - dp.galley[from].y
+				dp.place(plan, from, last, float64(c)*step, cdy)
+				lowest = min(lowest, dp.galley[last].y+cdy)
+				from = last + 1
+			}
+			return after()
+		}
+	}
+
+	from := i
+	for c := 0; c < n && from < end; c++ {
+		cdy := colTop - dp.galley[from].y
+		best, bestCost := -1, gomath.MaxInt
+		forced, overflow := false, false
+
+		for k := from; k < end; k++ {
+			box := dp.galley[k]
+			if k > from && box.y+cdy < plan.bottom {
+				overflow = true
+				break
+			}
+			if k+1 == len(dp.galley) {
+				break
+			}
+			penalty := dp.breakPenalty(k)
+			if penalty <= forcedBreak {
+				best, forced = k, true
+				break
+			}
+			if penalty < noBreak {
+				cost := dp.pageBadness(box.y+cdy-plan.bottom, false) + penalty
+				if cost <= bestCost {
+					best, bestCost = k, cost
+				}
+			}
+		}
+
+		switch {
+		case forced:
+		case overflow:
+			if best < 0 {
+				best = from
+				for best+1 < end && dp.galley[best+1].y+cdy >= plan.bottom {
+					best++
+				}
+			}
+		case closed:
+			best = end - 1
+		default:
+			// The column is still filling up
+			return i, dy, false
+		}
+
+		dp.place(plan, from, best, float64(c)*step, cdy)
+		lowest = min(lowest, dp.galley[best].y+cdy)
+		from = best + 1
+		if forced && dp.galley[best].clear {
+			plan.forced = true
+			return from, dy, true
+		}
+	}
+
+	if from < end {
+		return from, dy, true
+	}
+	return after()
+}
+
+// balanceColumns finds the shortest column height, up to height, at which
+// the boxes from i up to end fit in n columns. It returns the last box of
+// each column.
+func (dp *DocumentProcessor) balanceColumns(i, end, n int, height float64) ([]int, bool) {
+	total := dp.galley[i].y - dp.galley[end-1].y
+	for h := total / float64(n); h <= height; h++ {
+		if breaks, ok := dp.packColumns(i, end, n, h); ok {
+			return breaks, true
+		}
+	}
+	return nil, false
+}
+
+// packColumns fills n columns of height h, each as far as it can break
+func (dp *DocumentProcessor) packColumns(i, end, n int, h float64) ([]int, bool) {
+	breaks := make([]int, n)
+	from := i
+	for c := range n {
+		last := from - 1
+		for k := from; k < end; k++ {
+			if dp.galley[from].y-dp.galley[k].y > h {
+				break
+			}
+			if k == end-1 {
+				last = k
+				break
+			}
+			penalty := dp.breakPenalty(k)
+			if penalty <= forcedBreak {
+				last = k
+				break
+			}
+			if penalty < noBreak {
+				last = k
+			}
+		}
+		if from < end && last < from {
+			return nil, false
+		}
+		breaks[c] = last
+		from = last + 1
+	}
+	return breaks, from == end
+}
+
+// place adds the boxes from up to and including to to the page
+func (dp *DocumentProcessor) place(plan *pagePlan, from, to int, dx, dy float64) {
+	for k := from; k <= to; k++ {
+		plan.placements = append(plan.placements, placement{box: dp.galley[k], dx: dx, dy: dy})
+	}
+	if to >= from {
+		plan.lowest = min(plan.lowest, dp.galley[to].y+dy)
+	}
+}
+
+// pageBadness rates a page that comes up short of the bottom margin.
+// Pages with \vfil or \vfill glue stretch to any length.
+func (dp *DocumentProcessor) pageBadness(short float64, stretchable bool) int {
+	if stretchable || short <= 0 {
+		return 0
+	}
+	ratio := short / (raggedBottomLines * dp.lineHeight)
+	if ratio > 10 {
+		return deplorable
+	}
+	badness := int(100 * ratio * ratio * ratio)
+	if badness >= noBreak {
+		return deplorable
+	}
+	return badness
+}
+
+// shipPage draws a planned page, using up its floats and the first used
+// galley boxes, and adds its running header and footer. On a page of
+// full-width text alone, the space left above the bottom is shared by the
+// glue of the highest order on the page, such as \vfill over \vfil. Glue
+// at the break itself only counts when the break was forced, as with the
+// \vfil of \newpage.
+func (dp *DocumentProcessor) shipPage(plan pagePlan, used int) {
+	dp.generator.StopRecording()
+	if dp.pagesShipped > 0 {
 		dp.generator.NewPage()
-		dp.currentY = dp.generator.PageHeight - dp.generator.MarginTop
-		dp.lineHasContent = false // Reset line state on new page
+	}
+
+	// Running heads span the page whatever the columns in use
+	marginRight := dp.generator.MarginRight
+	dp.generator.MarginRight = dp.generator.PageWidth - dp.generator.MarginLeft - dp.textWidth
+
+	for _, f := range plan.floats {
+		dp.generator.ReplayRecording(f.float.recording, 0, f.y)
+	}
+	dp.floats = dp.floats[len(plan.floats):]
+
+	stretch, glue := dp.pageStretch(plan)
+	offset := 0.0
+	for _, p := range plan.placements {
+		dp.replayBox(p.box, p.dx, p.dy-offset)
+		offset += stretch * glue(p.box)
+	}
+
+	dp.finishPage()
+	dp.startPage()
+	dp.pagesShipped++
+	dp.galley = dp.galley[used:]
+	dp.boxesShipped += used
+	dp.generator.MarginRight = marginRight
+	dp.generator.StartRecording()
+}
+
+// pageStretch returns how far each unit of glue on the page stretches and
+// the glue after a box, or no stretch unless the page is one run of
+// full-width text
+func (dp *DocumentProcessor) pageStretch(plan pagePlan) (float64, func(galleyBox) float64) {
+	none := func(galleyBox) float64 { return 0 }
+	if len(plan.floats) > 0 || len(plan.placements) == 0 {
+		return 0, none
+	}
+	dy := plan.placements[0].dy
+	for _, p := range plan.placements {
+		if p.dx != 0 || p.dy != dy {
+			return 0, none
+		}
+	}
+
+	last := len(plan.placements) - 1
+	var total [filll]float64
+	for i, p := range plan.placements {
+		if i < last || plan.forced {
+			for order, amount := range p.box.stretch {
+				total[order] += amount
+			}
+		}
+	}
+	short := plan.placements[last].box.y + dy - plan.bottom
+	for order := filll - 1; order >= 0; order-- {
+		if total[order] > 0 && short > 0 {
+			return short / total[order], func(box galleyBox) float64 { return box.stretch[order] }
+		}
+	}
+	return 0, none
+}
+
+// replayBox draws a box moved by dx and dy, moving what follows its
+// horizontal glue further across
+func (dp *DocumentProcessor) replayBox(box galleyBox, dx, dy float64) {
+	from, offset := box.start, 0.0
+	for _, s := range box.shifts {
+		dp.generator.Replay(from, s.from, dx+offset, dy)
+		from, offset = s.from, s.dx
+	}
+	dp.generator.Replay(from, box.end, dx+offset, dy)
+}
+
+// finishPages ships the remaining content once the document is processed.
+// Like \end{document}, it ends the last page with \clearpage, balancing
+// the columns of a two-column document as the balance package does.
+func (dp *DocumentProcessor) finishPages() {
+	// Notes after the last drawing have no box to join
+	if end := dp.generator.Recorded(); end > dp.boxStart {
+		dp.addBox(end, nil)
+	}
+	dp.addStretch(1, fil)
+	dp.paragraph++
+	dp.buildPages(true)
+	dp.generator.StopRecording()
+}
+
+// breakPage handles \newpage, \clearpage, \pagebreak and \nopagebreak. The
+// optional argument 0-4 of the last two sets how strongly to ask. In
+// columns, all but \clearpage break the column instead of the page.
+func (dp *DocumentProcessor) breakPage(name string, priority int) {
+	penalties := []int{0, 51, 151, 301, noBreak}
+	if priority < 0 || priority > 4 {
+		priority = 4
+	}
+
+	switch name {
+	case "newpage", "clearpage":
+		if dp.lineHasContent {
+			dp.newLine()
+		}
+		dp.addStretch(1, fil)
+		dp.addPenalty(forcedBreak)
+		if name == "clearpage" {
+			dp.galley[len(dp.galley)-1].clear = true
+		}
+		dp.endParagraph()
+	case "pagebreak":
+		if priority == 4 {
+			dp.addPenalty(forcedBreak)
+		} else {
+			dp.addPenalty(-penalties[priority])
+		}
+	case "nopagebreak":
+		dp.addPenalty(penalties[priority])
 	}
 }
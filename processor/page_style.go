@@ -0,0 +1,392 @@
+package processor
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rickykimani/gotex/parser"
+	"github.com/rickykimani/gotex/pdf"
+)
+
+const (
+	// headSep is the distance from the top of the text area up to the
+	// header baseline, and footSkip from the bottom of the text area down to
+	// the footer baseline
+	headSep  = 25.0
+	footSkip = 30.0
+
+	// headRuleWidth is fancyhdr's default rule under the header
+	headRuleWidth = 0.4
+)
+
+// pageState tracks page numbering, page styles and the marks running
+// headers are built from
+type pageState struct {
+	style     string // \pagestyle: empty, plain, headings or fancy
+	thisStyle string // \thispagestyle for the current page only
+	numbering string // \pagenumbering: arabic, roman, Roman, alph or Alph
+	number    int
+	twoside   bool
+
+	// \leftmark is the last left mark set so far; \rightmark is the first
+	// right mark set on the page, or the one carried over from earlier pages
+	leftMark, rightMark string
+	firstRightMark      string
+	pageHasMark         bool
+
+	// fancyhdr slots keyed by slotKey, such as "head/L/O", and whether
+	// fancyhdr's defaults have been put in them
+	fancy         map[string]parser.Node
+	fancyDefaults bool
+}
+
+func newPageState() pageState {
+	return pageState{
+		style:     "plain",
+		numbering: "arabic",
+		number:    1,
+		fancy:     make(map[string]parser.Node),
+	}
+}
+
+func slotKey(part string, position, side byte) string {
+	return part + "/" + string(position) + "/" + string(side)
+}
+
+// processPageStyleCommand handles page styles, numbering, marks and the
+// fancyhdr commands. They take effect when the page they land on is
+// shipped, so that they apply to that page and the ones after it.
+func (dp *DocumentProcessor) processPageStyleCommand(cmd *parser.Command) {
+	dp.generator.Defer(func() {
+		dp.applyPageStyleCommand(cmd)
+	})
+}
+
+func (dp *DocumentProcessor) applyPageStyleCommand(cmd *parser.Command) {
+	arg := func(i int) string {
+		if i < len(cmd.Args) {
+			return strings.TrimSpace(dp.extractText(cmd.Args[i]))
+		}
+		return ""
+	}
+
+	switch cmd.Name {
+	case "pagestyle":
+		dp.setPageStyle(arg(0))
+
+	case "thispagestyle":
+		dp.page.thisStyle = arg(0)
+
+	case "pagenumbering":
+		dp.page.numbering = arg(0)
+		dp.page.number = 1
+
+	case "markboth":
+		dp.setMarks(arg(0), arg(1), true)
+
+	case "markright":
+		dp.setMarks("", arg(0), false)
+
+	case "fancyhf":
+		dp.setFancySlots("HF", cmd)
+
+	case "fancyhead":
+		dp.setFancySlots("H", cmd)
+
+	case "fancyfoot":
+		dp.setFancySlots("F", cmd)
+
+	case "lhead", "chead", "rhead", "lfoot", "cfoot", "rfoot":
+		// fancyhdr's older interface sets one slot on both sides
+		if len(cmd.Args) > 0 {
+			part := "head"
+			if strings.HasSuffix(cmd.Name, "foot") {
+				part = "foot"
+			}
+			position := strings.ToUpper(cmd.Name[:1])[0]
+			dp.installFancyDefaults()
+			for _, side := range []byte("EO") {
+				dp.page.fancy[slotKey(part, position, side)] = cmd.Args[0]
+			}
+		}
+	}
+}
+
+// setPageStyle switches the page style
+func (dp *DocumentProcessor) setPageStyle(style string) {
+	dp.page.style = style
+	if style == "fancy" {
+		dp.installFancyDefaults()
+	}
+}
+
+// installFancyDefaults puts fancyhdr's default header and footer in the
+// slots the first time the fancy style or its slots are used, as loading
+// fancyhdr does, so that slots changed or cleared since keep their content
+func (dp *DocumentProcessor) installFancyDefaults() {
+	if dp.page.fancyDefaults {
+		return
+	}
+	dp.page.fancyDefaults = true
+
+	mark := func(name string) parser.Node {
+		return &parser.Group{Nodes: []parser.Node{&parser.Command{Name: "slshape"}, &parser.Command{Name: name}}}
+	}
+	dp.page.fancy[slotKey("head", 'L', 'E')] = mark("rightmark")
+	dp.page.fancy[slotKey("head", 'R', 'O')] = mark("rightmark")
+	dp.page.fancy[slotKey("head", 'L', 'O')] = mark("leftmark")
+	dp.page.fancy[slotKey("head", 'R', 'E')] = mark("leftmark")
+	for _, side := range []byte("EO") {
+		dp.page.fancy[slotKey("foot", 'C', side)] = &parser.Command{Name: "thepage"}
+	}
+}
+
+// setFancySlots fills the slots selected by the optional argument, such as
+// [LE,RO], with the command's argument. Letters left out select every
+// position, side or part; parts are H for the header and F for the footer.
+func (dp *DocumentProcessor) setFancySlots(parts string, cmd *parser.Command) {
+	dp.installFancyDefaults()
+
+	var content parser.Node
+	if len(cmd.Args) > 0 {
+		content = cmd.Args[0]
+	}
+
+	specs := []string{""}
+	if len(cmd.Optional) > 0 {
+		specs = strings.Split(dp.extractText(cmd.Optional[0]), ",")
+	}
+
+	for _, spec := range specs {
+		spec = strings.ToUpper(strings.TrimSpace(spec))
+		pick := func(all string) string {
+			var chosen strings.Builder
+			for _, letter := range all {
+				if strings.ContainsRune(spec, letter) {
+					chosen.WriteRune(letter)
+				}
+			}
+			if chosen.Len() == 0 {
+				return all
+			}
+			return chosen.String()
+		}
+
+		for _, part := range pick(parts) {
+			name := "head"
+			if part == 'F' {
+				name = "foot"
+			}
+			for _, position := range []byte(pick("LCR")) {
+				for _, side := range []byte(pick("EO")) {
+					if content == nil {
+						delete(dp.page.fancy, slotKey(name, position, side))
+					} else {
+						dp.page.fancy[slotKey(name, position, side)] = content
+					}
+				}
+			}
+		}
+	}
+}
+
+// setMarks updates \leftmark and \rightmark; markright leaves the left mark
+func (dp *DocumentProcessor) setMarks(left, right string, both bool) {
+	if both {
+		dp.page.leftMark = left
+	}
+	dp.page.rightMark = right
+	if !dp.page.pageHasMark {
+		dp.page.firstRightMark = right
+		dp.page.pageHasMark = true
+	}
+}
+
+// sectionMark sets the marks for a new section the way article does, when
+// its page is shipped. Two-sided documents and fancyhdr, once
+// \pagestyle{fancy} is in use, set both marks; others only the right one.
+func (dp *DocumentProcessor) sectionMark(number, title string) {
+	dp.generator.Defer(func() {
+		mark := strings.ToUpper(number + "  " + title)
+		if dp.page.style == "fancy" || dp.page.twoside {
+			dp.setMarks(mark, "", true)
+		} else {
+			dp.setMarks("", mark, false)
+		}
+	})
+}
+
+// subsectionMark sets the right mark for a new subsection
+func (dp *DocumentProcessor) subsectionMark(number, title string) {
+	dp.generator.Defer(func() {
+		if dp.page.style == "fancy" || dp.page.twoside {
+			dp.setMarks("", number+"  "+title, false)
+		}
+	})
+}
+
+// formatPageNumber formats n in the current \pagenumbering style
+func (dp *DocumentProcessor) formatPageNumber(n int) string {
+	switch dp.page.numbering {
+	case "roman":
+		return strings.ToLower(toRoman(n))
+	case "Roman":
+		return toRoman(n)
+	case "alph":
+		return strings.ToLower(toAlph(n))
+	case "Alph":
+		return toAlph(n)
+	default:
+		return strconv.Itoa(n)
+	}
+}
+
+func toRoman(n int) string {
+	if n <= 0 {
+		return strconv.Itoa(n)
+	}
+
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	numerals := []string{"M", "CM", "D", "CD", "C", "XC", "L", "XL", "X", "IX", "V", "IV", "I"}
+	var b strings.Builder
+	for i, value := range values {
+		for n >= value {
+			b.WriteString(numerals[i])
+			n -= value
+		}
+	}
+	return b.String()
+}
+
+// toAlph numbers 1-26 as A-Z like LaTeX's \Alph; other values fall back to digits
+func toAlph(n int) string {
+	if n < 1 || n > 26 {
+		return strconv.Itoa(n)
+	}
+	return string(rune('A' + n - 1))
+}
+
+// finishPage draws the header and footer of the current page once its
+// content is drawn, when the marks set on the page are known
+func (dp *DocumentProcessor) finishPage() {
+	style := dp.page.style
+	if dp.page.thisStyle != "" {
+		style = dp.page.thisStyle
+	}
+
+	rightMark := dp.page.rightMark
+	if dp.page.pageHasMark {
+		rightMark = dp.page.firstRightMark
+	}
+
+	// Running heads are drawn in black and are never links
+	color := dp.generator.Color()
+	dp.generator.SetColor(pdf.Black)
+	link := dp.link
+	dp.link = nil
+
+	headY := dp.generator.PageHeight - dp.generator.MarginTop + headSep
+	footY := dp.generator.MarginBottom - footSkip
+	page := dp.formatPageNumber(dp.page.number)
+	odd := !dp.page.twoside || dp.page.number%2 == 1
+
+	switch style {
+	case "plain":
+		dp.drawRunningText(page, "normal", 'C', footY)
+
+	case "headings":
+		// Page number outside, mark inside; one-sided documents use the odd layout
+		if odd {
+			dp.drawRunningText(rightMark, "italic", 'L', headY)
+			dp.drawRunningText(page, "normal", 'R', headY)
+		} else {
+			dp.drawRunningText(page, "normal", 'L', headY)
+			dp.drawRunningText(dp.page.leftMark, "italic", 'R', headY)
+		}
+
+	case "fancy":
+		side := byte('O')
+		if !odd {
+			side = 'E'
+		}
+		for _, position := range []byte("LCR") {
+			if node, ok := dp.page.fancy[slotKey("head", position, side)]; ok {
+				text, style := dp.runningText(node, page, rightMark)
+				dp.drawRunningText(text, style, position, headY)
+			}
+			if node, ok := dp.page.fancy[slotKey("foot", position, side)]; ok {
+				text, style := dp.runningText(node, page, rightMark)
+				dp.drawRunningText(text, style, position, footY)
+			}
+		}
+		ruleY := headY - dp.fontSize*0.3
+		dp.generator.AddFilledRect(dp.generator.MarginLeft, ruleY, dp.generator.GetContentWidth(), headRuleWidth, pdf.Black)
+	}
+
+	dp.link = link
+	dp.generator.SetColor(color)
+}
+
+// startPage resets the per-page state after a new page is started
+func (dp *DocumentProcessor) startPage() {
+	dp.page.number++
+	dp.page.thisStyle = ""
+	dp.page.pageHasMark = false
+}
+
+// drawRunningText draws header or footer text at the left, center or right
+// of the text width
+func (dp *DocumentProcessor) drawRunningText(text, style string, position byte, y float64) {
+	if text == "" {
+		return
+	}
+
+	x := dp.generator.MarginLeft
+	switch position {
+	case 'C':
+		x = (dp.generator.PageWidth - dp.calculateTextWidth(text, style)) / 2
+	case 'R':
+		x = dp.generator.PageWidth - dp.generator.MarginRight - dp.calculateTextWidth(text, style)
+	}
+	dp.generator.AddText(text, x, y, dp.fontSize, style)
+}
+
+// runningText flattens a header or footer slot to its text, substituting
+// \thepage and the marks. Font commands in the slot choose its style.
+func (dp *DocumentProcessor) runningText(node parser.Node, page, rightMark string) (string, string) {
+	style := "normal"
+	var b strings.Builder
+
+	var walk func(parser.Node)
+	walk = func(node parser.Node) {
+		switch n := node.(type) {
+		case *parser.TextNode:
+			b.WriteString(n.Value)
+		case *parser.Group:
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parser.Command:
+			switch n.Name {
+			case "thepage":
+				b.WriteString(page)
+			case "leftmark":
+				b.WriteString(dp.page.leftMark)
+			case "rightmark":
+				b.WriteString(rightMark)
+			case "today":
+				b.WriteString(dp.extractText(n))
+			case "slshape", "itshape", "textit", "textsl", "emph":
+				style = "italic"
+			case "bfseries", "textbf":
+				style = "bold"
+			}
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		}
+	}
+	walk(node)
+
+	return strings.TrimSpace(b.String()), style
+}
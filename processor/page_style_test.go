@@ -0,0 +1,94 @@
+package processor
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/rickykimani/gotex/lexer"
+	"github.com/rickykimani/gotex/parser"
+	"github.com/rickykimani/gotex/pdf"
+	"github.com/rickykimani/gotex/ttf"
+)
+
+func TestFancySlots(t *testing.T) {
+	defaults := []string{"foot/C/E", "foot/C/O", "head/L/E", "head/L/O", "head/R/E", "head/R/O"}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string // Filled slots
+	}{
+		{"defaults", `\pagestyle{fancy}`, defaults},
+		{"cleared before fancy", `\fancyhf{}\pagestyle{fancy}`, nil},
+		{"cleared after fancy", `\pagestyle{fancy}\fancyhf{}\pagestyle{fancy}`, nil},
+		{"cleared and set", `\fancyhf{}\fancyhead[C]{Title}\pagestyle{fancy}`, []string{"head/C/E", "head/C/O"}},
+		{"footer cleared", `\fancyfoot{}\pagestyle{fancy}`, []string{"head/L/E", "head/L/O", "head/R/E", "head/R/O"}},
+		{"slot changed", `\lhead{Left}\pagestyle{fancy}`, defaults},
+		{"not fancy", `\pagestyle{plain}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator, err := pdf.NewGenerator(ttf.FS)
+			if err != nil {
+				t.Fatalf("creating generator: %v", err)
+			}
+			dp := NewDocumentProcessor(generator)
+
+			nodes, _ := parser.NewParser(lexer.NewLexer(tt.input).Tokenize()).Parse()
+			for _, node := range nodes.Body {
+				if cmd, ok := node.(*parser.Command); ok {
+					dp.applyPageStyleCommand(cmd)
+				}
+			}
+
+			var slots []string
+			for key := range dp.page.fancy {
+				slots = append(slots, key)
+			}
+			sort.Strings(slots)
+			if !reflect.DeepEqual(slots, tt.expected) {
+				t.Errorf("expected slots %v, got %v", tt.expected, slots)
+			}
+		})
+	}
+}
+
+func TestSectionMarks(t *testing.T) {
+	tests := []struct {
+		name            string
+		style           string
+		twoside         bool
+		left, right     string // After the section
+		subsectionRight string
+	}{
+		{"fancy", "fancy", false, "2  INTRO", "", "2.1  Setup"},
+		{"twoside headings", "headings", true, "2  INTRO", "", "2.1  Setup"},
+		{"headings", "headings", false, "", "2  INTRO", "2  INTRO"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator, err := pdf.NewGenerator(ttf.FS)
+			if err != nil {
+				t.Fatalf("creating generator: %v", err)
+			}
+			dp := NewDocumentProcessor(generator)
+			dp.page.style = tt.style
+			dp.page.twoside = tt.twoside
+
+			// Marks are set as soon as they are drawn
+			generator.StopRecording()
+
+			dp.sectionMark("2", "Intro")
+			if dp.page.leftMark != tt.left || dp.page.rightMark != tt.right {
+				t.Errorf("section: expected marks %q and %q, got %q and %q", tt.left, tt.right, dp.page.leftMark, dp.page.rightMark)
+			}
+			dp.subsectionMark("2.1", "Setup")
+			if dp.page.rightMark != tt.subsectionRight {
+				t.Errorf("subsection: expected right mark %q, got %q", tt.subsectionRight, dp.page.rightMark)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rickykimani/gotex/lexer"
+	"github.com/rickykimani/gotex/parser"
+	"github.com/rickykimani/gotex/pdf"
+	"github.com/rickykimani/gotex/ttf"
+)
+
+// TestHeadingKeptWithText sets sections of three-line paragraphs, which
+// bring headings to every height near the bottom of a page, and checks
+// that each heading lands on the page with the first two lines of its text
+func TestHeadingKeptWithText(t *testing.T) {
+	generator, err := pdf.NewGenerator(ttf.FS)
+	if err != nil {
+		t.Fatalf("creating generator: %v", err)
+	}
+	dp := NewDocumentProcessor(generator)
+
+	process := func(input string) {
+		doc, _ := parser.NewParser(lexer.NewLexer(input).Tokenize()).Parse()
+		dp.processNodes(doc.Body, "normal")
+	}
+
+	// Words too wide to share a line, so that the page of each line can be
+	// noted after its word
+	word := strings.Repeat("m", 40)
+
+	const sections = 30
+	pages := make([][]int, sections)
+	for s := range pages {
+		process(fmt.Sprintf("\\section{S%d}\n", s+1))
+		for line := 0; line < 3; line++ {
+			process(word)
+			dp.generator.Defer(func() { pages[s] = append(pages[s], dp.page.number) })
+		}
+		process("\n\n")
+	}
+	dp.finishPages()
+
+	moved := false
+	for s, lines := range pages {
+		heading := dp.anchorPages[fmt.Sprintf("section.%d", s+1)]
+		for i, page := range lines[:2] {
+			if got := dp.formatPageNumber(page); got != heading {
+				t.Errorf("section %d: heading on page %s, line %d of its text on page %s", s+1, heading, i+1, got)
+			}
+		}
+		if s > 0 && lines[0] > pages[s-1][2] {
+			moved = true
+		}
+	}
+	if !moved {
+		t.Errorf("expected a section to start a new page")
+	}
+}
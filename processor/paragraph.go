@@ -4,6 +4,7 @@ func (dp *DocumentProcessor) addParagraphBreak() {
 	if dp.lineHasContent {
 		dp.newLine()
 	}
+	dp.endParagraph()
 	dp.addVerticalSpace(dp.lineHeight * 0.8)
 	dp.lineHasContent = false
 	dp.currentLineX = dp.generator.MarginLeft
@@ -1,9 +1,13 @@
 package processor
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/rickykimani/gotex/math"
 	"github.com/rickykimani/gotex/parser"
 	"github.com/rickykimani/gotex/pdf"
+	"github.com/rickykimani/gotex/typesetter"
 )
 
 type DocumentProcessor struct {
@@ -26,12 +30,86 @@ type DocumentProcessor struct {
 	listCounters []int
 
 	// Section counters
-	sectionCounter    int
-	subsectionCounter int
-	equationCounter   int
+	sectionCounter       int
+	subsectionCounter    int
+	subsubsectionCounter int
+	equationCounter      int
+
+	// Listing counter for captioned code listings
+	listingCounter int
+
+	// Figure and table counters, and the kind of float being laid out, which
+	// \caption numbers
+	figureCounter int
+	tableCounter  int
+	floatKind     string
+
+	// Colors set by \color, innermost last, and colors added by \definecolor
+	colorStack    []pdf.Color
+	definedColors map[string]pdf.Color
+
+	// Cross-references collected before drawing, the page each link target
+	// landed on, and whether a table of contents awaits its page numbers
+	refs          *references
+	anchorPages   map[string]string
+	contentsDrawn bool
+
+	// Page numbering, page styles and running header marks
+	page pageState
+
+	// Boxes waiting for the page builder, the first recorded operation of
+	// the box being drawn, and what applies to it once it is closed
+	galley         []galleyBox
+	boxStart       int
+	pendingPenalty int
+	pendingEnlarge float64
+	pendingKeep    float64
+	pagesShipped   int
+	boxesShipped   int
+
+	// Column layout: the full text width, the number of columns text is set
+	// in and the run of boxes set that way, which grows at every change
+	textWidth float64
+	columns   int
+	run       int
+
+	// Floats waiting for a page, whether one is being laid out, and how many
+	// environments keep their lines on one page
+	floats       []*pageFloat
+	inFloat      bool
+	keepTogether int
+
+	// Current paragraph and line for club and widow penalties, which come
+	// from the line breaker's penalty table
+	paragraph     int
+	paragraphLine int
+	afterHeading  bool
+	penalties     map[string]int
+
+	// hyperref settings and the link words are currently part of, if any
+	hyperref hyperrefOptions
+	link     *linkTarget
+
+	// Extra indent of continuation lines, as in bibliography entries
+	hangIndent float64
+
+	// Time of the build, used for \today and the PDF dates
+	buildTime time.Time
+
+	// Directory that relative paths in the document are resolved against
+	baseDir string
+
+	// Problems that did not stop processing, reported by the caller
+	warnings []string
 
 	// Line state tracking
 	lineHasContent bool // Track if current line has content
+	lineBroken     bool // Whether the line was started by breaking a full one
+	lineEnded      bool // Whether \\ just ended a line, so a line break after it adds nothing
+
+	// Infinite glue on the current line, and vertical space to add after it
+	lineGlues []lineGlue
+	lineSkips []lineSkip
 
 	// Track if we just processed a command for spacing logic
 	lastProcessedCommand bool
@@ -40,12 +118,16 @@ type DocumentProcessor struct {
 func NewDocumentProcessor(generator *pdf.Generator) *DocumentProcessor {
 	generator.NewPage() // Ensure we have at least one page
 
+	// Content is laid out first and placed on pages by the page builder
+	generator.StartRecording()
+
 	fontSize := 12.0
+	top := generator.PageHeight - generator.MarginTop
 
-	return &DocumentProcessor{
+	dp := &DocumentProcessor{
 		generator:            generator,
 		mathProcessor:        math.NewMathProcessor(generator, fontSize),
-		currentY:             generator.PageHeight - generator.MarginTop,
+		currentY:             top,
 		currentX:             0,
 		currentLineX:         generator.MarginLeft,
 		lineHeight:           20.0,
@@ -53,14 +135,49 @@ func NewDocumentProcessor(generator *pdf.Generator) *DocumentProcessor {
 		listLevel:            0,
 		listType:             make([]string, 0),
 		listCounters:         make([]int, 0),
+		definedColors:        make(map[string]pdf.Color),
+		anchorPages:          make(map[string]string),
+		page:                 newPageState(),
+		galley:               []galleyBox{{y: top, paragraph: -1}}, // marks the top of the first page
+		textWidth:            generator.GetContentWidth(),
+		columns:              1,
+		penalties:            typesetter.NewLineBreak(generator.GetContentWidth()).Penalties,
+		hyperref:             defaultHyperrefOptions(),
+		buildTime:            time.Now(),
 		sectionCounter:       0,
 		subsectionCounter:    0,
 		equationCounter:      0,
 		lineHasContent:       false,
 		lastProcessedCommand: false,
 	}
+	dp.mathProcessor.SetTextSetter(dp.setMathText)
+	return dp
 }
 
 func (dp *DocumentProcessor) ProcessDocument(nodes []parser.Node) {
+	dp.collectReferences(nodes)
 	dp.processNodes(nodes, "normal")
+	dp.finishPages()
+	dp.finishReferences()
+}
+
+// SetBaseDir sets the directory used to resolve files included by the
+// document, normally the directory of the input file
+func (dp *DocumentProcessor) SetBaseDir(dir string) {
+	dp.baseDir = dir
+}
+
+// SetBuildTime sets the time used for \today and the PDF modification date,
+// so that builds can be reproduced by fixing it
+func (dp *DocumentProcessor) SetBuildTime(t time.Time) {
+	dp.buildTime = t
+}
+
+// Warnings returns the problems found while processing the document
+func (dp *DocumentProcessor) Warnings() []string {
+	return dp.warnings
+}
+
+func (dp *DocumentProcessor) warn(format string, args ...any) {
+	dp.warnings = append(dp.warnings, fmt.Sprintf(format, args...))
 }
@@ -0,0 +1,343 @@
+package processor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rickykimani/gotex/parser"
+)
+
+// reference is what a \ref or \cite resolves to: the text shown in place of
+// the command and the anchor it links to
+type reference struct {
+	text   string
+	anchor string
+}
+
+// contentsEntry is one line of the table of contents
+type contentsEntry struct {
+	level  int // 1 section, 2 subsection, 3 subsubsection
+	number string
+	title  string
+	anchor string
+}
+
+// references holds everything that can be referred to before it is drawn.
+// It is filled by collectReferences ahead of processing so that forward
+// references and the table of contents resolve in a single pass.
+type references struct {
+	labels    map[string]reference
+	citations map[string]reference
+	contents  []contentsEntry
+}
+
+// referenceScanner walks the document keeping the same counters the
+// processor does, so numbers and anchors agree with what is drawn later
+type referenceScanner struct {
+	dp   *DocumentProcessor
+	refs *references
+
+	section, subsection, subsubsection int
+	equation, listing, bibitem         int
+	figure, table                      int
+
+	// The most recent numbered item, which \label refers to
+	current reference
+}
+
+// collectReferences records labels, bibliography entries and headings
+func (dp *DocumentProcessor) collectReferences(nodes []parser.Node) {
+	s := &referenceScanner{
+		dp: dp,
+		refs: &references{
+			labels:    make(map[string]reference),
+			citations: make(map[string]reference),
+		},
+	}
+	s.walk(nodes)
+	dp.refs = s.refs
+}
+
+func (s *referenceScanner) walk(nodes []parser.Node) {
+	for _, node := range nodes {
+		s.walkNode(node)
+	}
+}
+
+func (s *referenceScanner) walkNode(node parser.Node) {
+	switch n := node.(type) {
+	case *parser.Group:
+		s.walk(n.Nodes)
+
+	case *parser.Command:
+		s.walkCommand(n)
+
+	case *parser.Environment:
+		switch n.Name {
+		case "equation":
+			var numbering equationNumbering
+			for _, node := range n.Body {
+				s.dp.takeNumbering(node, &numbering)
+			}
+			if number := numbering.number(s.section, &s.equation); number != "" {
+				s.current = reference{text: number, anchor: "equation." + number}
+			}
+
+		case "align", "align*", "gather", "gather*", "multline", "multline*":
+			// Each line is numbered on its own, and labels refer to the
+			// line they are on
+			for _, line := range s.dp.displayLines(n) {
+				if number := line.number(s.section, &s.equation); number != "" {
+					s.current = reference{text: number, anchor: "equation." + number}
+				}
+				if line.label != "" {
+					s.refs.labels[line.label] = s.current
+				}
+			}
+			return
+
+		case "lstlisting", "minted":
+			s.addListing(n.Optional)
+
+		case "figure", "figure*":
+			s.figure++
+			number := strconv.Itoa(s.figure)
+			s.current = reference{text: number, anchor: "figure." + number}
+
+		case "table", "table*":
+			s.table++
+			number := strconv.Itoa(s.table)
+			s.current = reference{text: number, anchor: "table." + number}
+
+		case "thebibliography":
+			s.bibitem = 0
+		}
+		s.walk(n.Body)
+	}
+}
+
+func (s *referenceScanner) walkCommand(cmd *parser.Command) {
+	text := func(i int) string {
+		if i < len(cmd.Args) {
+			return strings.TrimSpace(s.dp.extractText(cmd.Args[i]))
+		}
+		return ""
+	}
+
+	switch cmd.Name {
+	case "section":
+		s.section++
+		s.subsection, s.subsubsection, s.equation = 0, 0, 0
+		number := strconv.Itoa(s.section)
+		s.addHeading(1, number, "section."+number, applyLigatures(text(0), "normal"))
+
+	case "subsection":
+		s.subsection++
+		s.subsubsection = 0
+		number := fmt.Sprintf("%d.%d", s.section, s.subsection)
+		s.addHeading(2, number, "subsection."+number, applyLigatures(text(0), "normal"))
+
+	case "subsubsection":
+		s.subsubsection++
+		number := fmt.Sprintf("%d.%d.%d", s.section, s.subsection, s.subsubsection)
+		s.addHeading(3, number, "subsubsection."+number, applyLigatures(text(0), "normal"))
+
+	case "label":
+		s.refs.labels[text(0)] = s.current
+
+	case "bibitem":
+		s.bibitem++
+		key := text(0)
+		label := strconv.Itoa(s.bibitem)
+		if len(cmd.Optional) > 0 {
+			label = s.dp.extractText(cmd.Optional[0])
+		}
+		s.refs.citations[key] = reference{text: label, anchor: "cite." + key}
+
+	case "lstinputlisting", "inputminted":
+		s.addListing(cmd.Optional)
+
+	default:
+		for _, arg := range cmd.Args {
+			s.walkNode(arg)
+		}
+	}
+}
+
+func (s *referenceScanner) addHeading(level int, number, anchor, title string) {
+	s.current = reference{text: number, anchor: anchor}
+	// Subsubsections are not numbered in the output
+	if level == 3 {
+		number = ""
+	}
+	s.refs.contents = append(s.refs.contents, contentsEntry{
+		level:  level,
+		number: number,
+		title:  title,
+		anchor: anchor,
+	})
+}
+
+// addListing counts a captioned listing; label= in its options names it
+func (s *referenceScanner) addListing(optional []parser.Node) {
+	opts := s.dp.parseListingOptions(optional)
+	if opts.caption == "" {
+		return
+	}
+	s.listing++
+	number := strconv.Itoa(s.listing)
+	s.current = reference{text: number, anchor: "listing." + number}
+	if opts.label != "" {
+		s.refs.labels[opts.label] = s.current
+	}
+}
+
+// formatEquationNumber numbers equations within sections once there are any
+func formatEquationNumber(section, equation int) string {
+	if section > 0 {
+		return fmt.Sprintf("%d.%d", section, equation)
+	}
+	return strconv.Itoa(equation)
+}
+
+// addTarget makes the current position the destination of links to anchor
+// and remembers the number of the page it lands on for the table of contents
+func (dp *DocumentProcessor) addTarget(anchor string) {
+	dp.generator.SetAnchor(anchor, dp.currentY)
+	dp.generator.Defer(func() {
+		dp.anchorPages[anchor] = dp.formatPageNumber(dp.page.number)
+	})
+}
+
+// addReference draws the number of a labelled item linked to it. Unknown
+// labels are shown as ?? like LaTeX does.
+func (dp *DocumentProcessor) addReference(key, format, style string) {
+	ref, ok := dp.refs.labels[key]
+	if !ok {
+		dp.warn("reference %q undefined", key)
+		dp.addText("??", "bold")
+		return
+	}
+	dp.addLinkedText(fmt.Sprintf(format, ref.text), style, linkTarget{anchor: ref.anchor}, dp.hyperref.linkColor)
+}
+
+// addCitation draws \cite[note]{key1,key2} as [1, 2, note]
+func (dp *DocumentProcessor) addCitation(cmd *parser.Command, style string) {
+	if len(cmd.Args) == 0 {
+		return
+	}
+
+	dp.addWord("[", style)
+	for i, key := range strings.Split(dp.extractText(cmd.Args[0]), ",") {
+		key = strings.TrimSpace(key)
+		if i > 0 {
+			dp.addText(", ", style)
+		}
+
+		ref, ok := dp.refs.citations[key]
+		if !ok {
+			dp.warn("citation %q undefined", key)
+			dp.addWord("?", "bold")
+			continue
+		}
+		dp.addLinkedText(ref.text, style, linkTarget{anchor: ref.anchor}, dp.hyperref.citeColor)
+	}
+	if len(cmd.Optional) > 0 {
+		dp.addText(", "+dp.extractText(cmd.Optional[0]), style)
+	}
+	dp.addWord("]", style)
+}
+
+// addTableOfContents lists the document's headings with links to them. Page
+// numbers are filled in by finishReferences once every heading is placed.
+func (dp *DocumentProcessor) addTableOfContents() {
+	dp.addSectionHeading("Contents", "")
+
+	em := dp.fontSize
+	pageWidth := 2 * em
+	right := dp.generator.PageWidth - dp.generator.MarginRight
+
+	for _, entry := range dp.refs.contents {
+		style := "normal"
+		indent, numberWidth := 0.0, 0.0
+		switch entry.level {
+		case 1:
+			style = "bold"
+			numberWidth = 1.5 * em
+			dp.addVerticalSpace(0.5 * em)
+		case 2:
+			indent, numberWidth = 1.5*em, 2.3*em
+		default:
+			indent, numberWidth = 3.8*em, 3.2*em
+		}
+
+		x := dp.generator.MarginLeft + indent
+		if entry.number != "" {
+			dp.generator.AddText(entry.number, x, dp.currentY, dp.fontSize, style)
+		}
+		dp.generator.AddText(entry.title, x+numberWidth, dp.currentY, dp.fontSize, style)
+		dp.generator.AddPlaceholder("toc."+entry.anchor, right-pageWidth, dp.currentY, pageWidth, dp.fontSize, style)
+		dp.generator.AddInternalLink(entry.anchor, x, dp.currentY-0.25*dp.fontSize, right-x, dp.fontSize)
+		dp.newLine()
+	}
+
+	dp.contentsDrawn = true
+	dp.addVerticalSpace(dp.lineHeight)
+}
+
+// addBibItem starts an entry of thebibliography with its label in the margin
+// of a hanging indent
+func (dp *DocumentProcessor) addBibItem(cmd *parser.Command) {
+	if len(cmd.Args) == 0 {
+		return
+	}
+	key := strings.TrimSpace(dp.extractText(cmd.Args[0]))
+
+	if dp.lineHasContent {
+		dp.newLine()
+	}
+	dp.addVerticalSpace(0.3 * dp.lineHeight)
+	dp.addTarget("cite." + key)
+
+	if ref, ok := dp.refs.citations[key]; ok {
+		dp.generator.AddText("["+ref.text+"]", dp.generator.MarginLeft, dp.currentY, dp.fontSize, "normal")
+	}
+	dp.currentLineX = dp.generator.MarginLeft + dp.hangIndent
+}
+
+// processBibliography draws thebibliography. Its argument is the widest
+// label, which sets the hanging indent of the entries.
+func (dp *DocumentProcessor) processBibliography(env *parser.Environment, style string) {
+	dp.addSectionHeading("References", "")
+
+	widest := "[99]"
+	if len(env.Args) > 0 {
+		widest = "[" + dp.extractText(env.Args[0]) + "]"
+	}
+	dp.hangIndent = dp.calculateTextWidth(widest, "normal") + dp.fontSize*0.5
+
+	dp.processNodes(env.Body, style)
+
+	dp.hangIndent = 0
+	dp.newLine()
+}
+
+// finishReferences fills in what is only known at the end of the document:
+// table of contents page numbers and the PDF metadata
+func (dp *DocumentProcessor) finishReferences() {
+	if dp.contentsDrawn {
+		for _, entry := range dp.refs.contents {
+			style := "normal"
+			if entry.level == 1 {
+				style = "bold"
+			}
+			page := dp.anchorPages[entry.anchor]
+			if err := dp.generator.FillPlaceholder("toc."+entry.anchor, page, dp.fontSize, style); err != nil {
+				dp.warn("table of contents: %v", err)
+			}
+		}
+	}
+
+	dp.setMetadata()
+}
@@ -7,48 +7,86 @@ import (
 //TODO: Style sections better
 
 func (dp *DocumentProcessor) addSection(text string) {
-	// space before: (3.5ex + 1ex)
-	ex := dp.fontSize * 0.5
-	dp.newLine()
-	dp.addVerticalSpace((3.5 + 1.0) * ex)
-
 	// Increment section counter and reset subsection counter
 	dp.sectionCounter++
 	dp.subsectionCounter = 0
+	dp.subsubsectionCounter = 0
 	dp.equationCounter = 0 // Reset equation counter for new section
 
 	// Add section number prefix
 	numberedText := fmt.Sprintf("%d %s", dp.sectionCounter, text)
-	dp.generator.AddSection(numberedText, dp.generator.MarginLeft, dp.currentY, dp.fontSize)
+	dp.addSectionHeading(numberedText, fmt.Sprintf("section.%d", dp.sectionCounter))
+	dp.sectionMark(fmt.Sprintf("%d", dp.sectionCounter), text)
+}
+
+// addSectionHeading draws a section-level heading. A non-empty anchor makes
+// the heading a link target with a bookmark in the PDF outline.
+func (dp *DocumentProcessor) addSectionHeading(text, anchor string) {
+	// space before: (3.5ex + 1ex)
+	ex := dp.fontSize * 0.5
+	dp.startHeading()
+	dp.addVerticalSpace((3.5 + 1.0) * ex)
+
+	if anchor != "" {
+		dp.addTarget(anchor)
+		dp.generator.AddOutline(text, 1, dp.currentY)
+	}
+	dp.generator.AddSection(text, dp.generator.MarginLeft, dp.currentY, dp.fontSize)
 	// space after: 2.3ex
-	dp.addVerticalSpace(2.3 * ex)
-	dp.lineHasContent = false // Reset line state
+	dp.endHeading(2.3 * ex)
 }
 
 func (dp *DocumentProcessor) addSubsection(text string) {
 	// space before: (3.25ex + 1ex)
 	ex := dp.fontSize * 0.5
-	dp.newLine()
+	dp.startHeading()
 	dp.addVerticalSpace((3.25 + 1.0) * ex)
 
 	// Increment subsection counter
 	dp.subsectionCounter++
+	dp.subsubsectionCounter = 0
 
 	// Add subsection number prefix
 	numberedText := fmt.Sprintf("%d.%d %s", dp.sectionCounter, dp.subsectionCounter, text)
+	dp.addTarget(fmt.Sprintf("subsection.%d.%d", dp.sectionCounter, dp.subsectionCounter))
+	dp.generator.AddOutline(numberedText, 2, dp.currentY)
 	dp.generator.AddSubsection(numberedText, dp.generator.MarginLeft, dp.currentY, dp.fontSize)
 	// space after: 1.5ex
-	dp.addVerticalSpace(1.5 * ex)
-	dp.lineHasContent = false // Reset line state
+	dp.endHeading(1.5 * ex)
+	dp.subsectionMark(fmt.Sprintf("%d.%d", dp.sectionCounter, dp.subsectionCounter), text)
 }
 
 func (dp *DocumentProcessor) addSubsubsection(text string) {
 	// space before: (3.25ex + 1ex)
 	ex := dp.fontSize * 0.5
-	dp.newLine()
+	dp.startHeading()
 	dp.addVerticalSpace((3.25 + 1.0) * ex)
+
+	// Subsubsections are unnumbered but still counted for their anchors
+	dp.subsubsectionCounter++
+	dp.addTarget(fmt.Sprintf("subsubsection.%d.%d.%d", dp.sectionCounter, dp.subsectionCounter, dp.subsubsectionCounter))
+	dp.generator.AddOutline(text, 3, dp.currentY)
 	dp.generator.AddText(text, dp.generator.MarginLeft, dp.currentY, dp.fontSize*1.05, "bold")
 	// space after: 1.5ex
-	dp.addVerticalSpace(1.5 * ex)
+	dp.endHeading(1.5 * ex)
+}
+
+// startHeading ends the paragraph before a heading, where a page break is
+// welcome
+func (dp *DocumentProcessor) startHeading() {
+	dp.newLine()
+	dp.endParagraph()
+	dp.addPenalty(sectionPenalty)
+}
+
+// endHeading adds the space after a heading, which keeps the heading with
+// the first two lines of the text that follows it
+func (dp *DocumentProcessor) endHeading(space float64) {
+	dp.addPenalty(noBreak)
+	dp.addVerticalSpace(space)
 	dp.lineHasContent = false // Reset line state
+
+	dp.paragraph++
+	dp.paragraphLine = 0
+	dp.afterHeading = true
 }
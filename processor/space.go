@@ -2,6 +2,7 @@ package processor
 
 import (
 	"strings"
+	"unicode"
 
 	"github.com/rickykimani/gotex/parser"
 )
@@ -12,6 +13,7 @@ func (dp *DocumentProcessor) addSpace(style string) {
 	// Check if adding space would exceed the line width
 	if dp.currentLineX+spaceWidth > dp.generator.PageWidth-dp.generator.MarginRight {
 		dp.newLine()
+		dp.lineBroken = true
 		return // Don't add space at the beginning of a new line
 	}
 
@@ -20,8 +22,222 @@ func (dp *DocumentProcessor) addSpace(style string) {
 }
 
 func (dp *DocumentProcessor) addVerticalSpace(space float64) {
+	dp.closeBox()
 	dp.currentY -= space
-	dp.checkNewPage()
+}
+
+// parIndent is the paragraph indent \indent adds, in em
+const parIndent = 1.5
+
+// textSpaces are the fixed horizontal spaces, in em, and whether a line
+// may break at them
+var textSpaces = map[string]struct {
+	em        float64
+	breakable bool
+}{
+	",":            {3.0 / 18, false},
+	"thinspace":    {3.0 / 18, false},
+	"!":            {-3.0 / 18, false},
+	"negthinspace": {-3.0 / 18, false},
+	":":            {4.0 / 18, false},
+	"medspace":     {4.0 / 18, false},
+	";":            {5.0 / 18, false},
+	"thickspace":   {5.0 / 18, false},
+	"enspace":      {0.5, false},
+	"enskip":       {0.5, true},
+	"quad":         {1, true},
+	"qquad":        {2, true},
+}
+
+// skips are the glue of \smallskip, \medskip and \bigskip, in pt
+var skips = map[string]float64{
+	"smallskip": 3,
+	"medskip":   6,
+	"bigskip":   12,
+}
+
+// isSpacingCommand reports whether a command only adds space, so that no
+// word space is added around it
+func isSpacingCommand(node parser.Node) bool {
+	cmd, ok := node.(*parser.Command)
+	if !ok {
+		return false
+	}
+	if _, ok := textSpaces[cmd.Name]; ok {
+		return true
+	}
+	if _, ok := skips[cmd.Name]; ok {
+		return true
+	}
+	switch cmd.Name {
+	case "hspace", "hspace*", "vspace", "vspace*", "hfil", "hfill", "vfil", "vfill", "indent", "noindent":
+		return true
+	}
+	return false
+}
+
+// isControlSymbol reports whether a node is a control symbol such as \%,
+// whose name is not a word, or a command such as \textendash that prints a
+// character. Either joins the text around it.
+func isControlSymbol(node parser.Node) bool {
+	cmd, ok := node.(*parser.Command)
+	if !ok {
+		return false
+	}
+	if _, ok := textSymbols[cmd.Name]; ok {
+		return true
+	}
+	return cmd.Name != "" && !unicode.IsLetter(rune(cmd.Name[0]))
+}
+
+// isReference reports whether a node is a cross-reference or citation,
+// which prints text in its place that joins the text around it
+func isReference(node parser.Node) bool {
+	cmd, ok := node.(*parser.Command)
+	if !ok {
+		return false
+	}
+	switch cmd.Name {
+	case "ref", "eqref", "cite":
+		return true
+	}
+	return false
+}
+
+// processSpacingCommand handles the commands that add horizontal or
+// vertical space
+func (dp *DocumentProcessor) processSpacingCommand(cmd *parser.Command) {
+	if space, ok := textSpaces[cmd.Name]; ok {
+		dp.addHorizontalGlue(glue{natural: space.em * dp.fontSize}, !space.breakable)
+		return
+	}
+	if points, ok := skips[cmd.Name]; ok {
+		// The stretch and shrink are a third of the size
+		pt := unitPoints["pt"]
+		dp.addVerticalGlue(glue{natural: points * pt, stretch: points / 3 * pt, shrink: points / 3 * pt}, false)
+		return
+	}
+
+	switch cmd.Name {
+	case "hspace", "hspace*", "vspace", "vspace*":
+		if len(cmd.Args) == 0 {
+			return
+		}
+		g, err := dp.parseGlue(dp.extractRawArgument(cmd.Args[0]))
+		if err != nil {
+			dp.warn("\\%s: %v", cmd.Name, err)
+			return
+		}
+		keep := strings.HasSuffix(cmd.Name, "*")
+		if strings.HasPrefix(cmd.Name, "h") {
+			dp.addHorizontalGlue(g, keep)
+		} else {
+			dp.addVerticalGlue(g, keep)
+		}
+
+	case "hfil":
+		dp.addHorizontalGlue(glue{stretch: 1, stretchOrder: fil}, false)
+
+	case "hfill":
+		dp.addHorizontalGlue(glue{stretch: 1, stretchOrder: fill}, false)
+
+	case "indent":
+		dp.addHorizontalGlue(glue{natural: parIndent * dp.fontSize}, true)
+
+	case "noindent":
+		// Paragraphs are not indented, so there is nothing to suppress
+	}
+}
+
+// lineGlue is infinitely stretchable glue on the current line, which pushes
+// the drawing recorded after it across
+type lineGlue struct {
+	op      int
+	stretch float64
+	order   int
+}
+
+// shift moves recorded drawing from an operation on by dx
+type shift struct {
+	from int
+	dx   float64
+}
+
+// lineSkip is vertical glue waiting for the end of the current line
+type lineSkip struct {
+	glue glue
+	keep bool
+}
+
+// addHorizontalGlue adds horizontal space to the current line. Glue where a
+// line was broken is dropped unless keep is set, as with \hspace*, which
+// also stops a line from breaking at it. Infinite stretch takes up the
+// space left at the end of the line.
+func (dp *DocumentProcessor) addHorizontalGlue(g glue, keep bool) {
+	if !dp.lineHasContent && dp.lineBroken && !keep {
+		return
+	}
+	if !keep && dp.lineHasContent && dp.currentLineX+g.natural > dp.generator.PageWidth-dp.generator.MarginRight {
+		dp.newLine()
+		dp.lineBroken = true
+		return
+	}
+
+	if g.stretchOrder > finite && g.stretch > 0 && !dp.inFloat {
+		dp.lineGlues = append(dp.lineGlues, lineGlue{op: dp.generator.Recorded(), stretch: g.stretch, order: g.stretchOrder})
+	}
+	dp.currentLineX += g.natural
+}
+
+// lineShifts shares the space left at the end of the current line among
+// its glue of the highest order, returning how far the drawing after each
+// glue moves
+func (dp *DocumentProcessor) lineShifts() []shift {
+	glues := dp.lineGlues
+	dp.lineGlues = nil
+
+	order, total := finite, 0.0
+	for _, g := range glues {
+		switch {
+		case g.order > order:
+			order, total = g.order, g.stretch
+		case g.order == order:
+			total += g.stretch
+		}
+	}
+	room := dp.generator.PageWidth - dp.generator.MarginRight - dp.currentLineX
+	if order == finite || total <= 0 || room <= 0 {
+		return nil
+	}
+
+	var shifts []shift
+	dx := 0.0
+	for _, g := range glues {
+		if g.order == order {
+			dx += room * g.stretch / total
+			shifts = append(shifts, shift{from: g.op, dx: dx})
+		}
+	}
+	return shifts
+}
+
+// addVerticalGlue adds vertical space, after the current line when there
+// is one. Space kept with keep, as by \vspace*, stays at the top of a page
+// instead of being dropped. Finite stretch and shrink are not used, since
+// pages are not stretched to the same height.
+func (dp *DocumentProcessor) addVerticalGlue(g glue, keep bool) {
+	if dp.lineHasContent {
+		dp.lineSkips = append(dp.lineSkips, lineSkip{glue: g, keep: keep})
+		return
+	}
+
+	dp.addVerticalSpace(g.natural)
+	if g.stretch > 0 {
+		dp.addStretch(g.stretch, g.stretchOrder)
+	}
+	if keep && !dp.inFloat {
+		dp.pendingKeep += g.natural
+	}
 }
 
 // shouldAddSpaceBetweenNodes determines if we need to add space between two adjacent nodes
@@ -31,11 +247,18 @@ func (dp *DocumentProcessor) shouldAddSpaceBetweenNodes(prev, curr parser.Node)
 	currStartsWithSpace := false
 
 	if prevText, ok := prev.(*parser.TextNode); ok {
-		prevEndsWithSpace = strings.HasSuffix(prevText.Value, " ") || strings.HasSuffix(prevText.Value, "\n") || strings.HasSuffix(prevText.Value, "\t")
+		prevEndsWithSpace = strings.HasSuffix(prevText.Value, " ") || strings.HasSuffix(prevText.Value, "\n") || strings.HasSuffix(prevText.Value, "\t") || strings.HasSuffix(prevText.Value, "~")
 	}
 
 	if currText, ok := curr.(*parser.TextNode); ok {
-		currStartsWithSpace = strings.HasPrefix(currText.Value, " ") || strings.HasPrefix(currText.Value, "\n") || strings.HasPrefix(currText.Value, "\t")
+		currStartsWithSpace = strings.HasPrefix(currText.Value, " ") || strings.HasPrefix(currText.Value, "\n") || strings.HasPrefix(currText.Value, "\t") || strings.HasPrefix(currText.Value, "~")
+	}
+
+	// Spacing commands make their own space, and the source keeps the
+	// spaces around control symbols and references
+	if isSpacingCommand(prev) || isSpacingCommand(curr) || isControlSymbol(prev) || isControlSymbol(curr) ||
+		isReference(prev) || isReference(curr) {
+		return false
 	}
 
 	// If neither has explicit spacing, we typically need to add space between nodes
@@ -0,0 +1,60 @@
+package processor
+
+import (
+	gomath "math"
+	"testing"
+
+	"github.com/rickykimani/gotex/lexer"
+	"github.com/rickykimani/gotex/parser"
+	"github.com/rickykimani/gotex/pdf"
+	"github.com/rickykimani/gotex/ttf"
+)
+
+// lineWidth returns the width of what set draws on a line after the labels
+// and bibliography of source are collected
+func lineWidth(t *testing.T, source string, set func(dp *DocumentProcessor)) float64 {
+	t.Helper()
+
+	generator, err := pdf.NewGenerator(ttf.FS)
+	if err != nil {
+		t.Fatalf("creating generator: %v", err)
+	}
+	dp := NewDocumentProcessor(generator)
+
+	doc, _ := parser.NewParser(lexer.NewLexer(source).Tokenize()).Parse()
+	dp.collectReferences(doc.Body)
+
+	start := dp.currentLineX
+	set(dp)
+	return dp.currentLineX - start
+}
+
+func TestReferenceSpacing(t *testing.T) {
+	source := `\section{One}\label{x}
+\begin{equation}\label{e}a\end{equation}
+\begin{thebibliography}{9}\bibitem{k} Book.\end{thebibliography}`
+
+	tests := []struct {
+		input    string
+		expected string // The same text without the commands
+	}{
+		{`(\ref{x})`, "(1)"},
+		{`\ref{x}.`, "1."},
+		{`\eqref{e}.`, "(1.1)."},
+		{`see~\cite{k}, and`, "see [1], and"},
+		{`Section \ref{x} ends`, "Section 1 ends"},
+	}
+
+	for _, tt := range tests {
+		got := lineWidth(t, source, func(dp *DocumentProcessor) {
+			nodes, _ := parser.NewParser(lexer.NewLexer(tt.input).Tokenize()).Parse()
+			dp.processNodes(nodes.Body, "normal")
+		})
+		expected := lineWidth(t, source, func(dp *DocumentProcessor) {
+			dp.addText(tt.expected, "normal")
+		})
+		if gomath.Abs(got-expected) > 0.01 {
+			t.Errorf("%s: expected width %.2f as for %q, got %.2f", tt.input, expected, tt.expected, got)
+		}
+	}
+}
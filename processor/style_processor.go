@@ -2,6 +2,40 @@ package processor
 
 import "github.com/rickykimani/gotex/parser"
 
+// emphasize returns the style \emph switches to from the given style:
+// upright text becomes italic and italic text becomes upright again
+func emphasize(style string) string {
+	switch style {
+	case "italic":
+		return "normal"
+	case "bold-italic":
+		return "bold"
+	case "bold":
+		return "bold-italic"
+	default:
+		return "italic"
+	}
+}
+
+// applyDeclaration handles declarations such as \em and \color that take
+// effect for the rest of the enclosing group. It returns the style to carry
+// on with and whether node was a declaration.
+func (dp *DocumentProcessor) applyDeclaration(node parser.Node, style string) (string, bool) {
+	cmd, ok := node.(*parser.Command)
+	if !ok {
+		return style, false
+	}
+
+	switch {
+	case cmd.Name == "em" && len(cmd.Args) == 0:
+		return emphasize(style), true
+	case cmd.Name == "color":
+		dp.processColorCommand(cmd, style)
+		return style, true
+	}
+	return style, false
+}
+
 // processStyledText processes a node with styling, ensuring text is split into words and spaces
 func (dp *DocumentProcessor) processStyledText(node parser.Node, style string) {
 	switch n := node.(type) {
@@ -11,7 +45,14 @@ func (dp *DocumentProcessor) processStyledText(node parser.Node, style string) {
 
 	case *parser.Group:
 		// Process each node in the group with the same style
+		defer dp.restoreColor(dp.colorDepth())
 		for i, child := range n.Nodes {
+			// Declarations apply to the remainder of the group
+			if newStyle, ok := dp.applyDeclaration(child, style); ok {
+				style = newStyle
+				continue
+			}
+
 			// Add space between group nodes when appropriate
 			if i > 0 {
 				prevChild := n.Nodes[i-1]
@@ -47,6 +88,10 @@ func (dp *DocumentProcessor) processStyledText(node parser.Node, style string) {
 			if len(n.Args) > 0 {
 				dp.processStyledText(n.Args[0], newStyle)
 			}
+		case "emph":
+			if len(n.Args) > 0 {
+				dp.processStyledText(n.Args[0], emphasize(style))
+			}
 		default:
 			// For other commands, process normally but preserve style
 			dp.processCommand(n, style)
@@ -2,7 +2,6 @@ package processor
 
 import (
 	"strings"
-	"time"
 
 	"github.com/rickykimani/gotex/parser"
 )
@@ -13,7 +12,7 @@ func (dp *DocumentProcessor) addText(text string, style string) {
 	}
 
 	// Normalize newlines to spaces
-	normalizedText := strings.ReplaceAll(text, "\n", " ")
+	normalizedText := applyLigatures(strings.ReplaceAll(text, "\n", " "), style)
 
 	// Process text character by character to preserve exact spacing
 	currentWord := ""
@@ -30,6 +29,9 @@ func (dp *DocumentProcessor) addText(text string, style string) {
 			if dp.lineHasContent {
 				dp.addSpace(style)
 			}
+		} else if char == '~' {
+			// A tie is a space the line cannot break at
+			currentWord += " "
 		} else {
 			currentWord += string(char)
 		}
@@ -41,10 +43,86 @@ func (dp *DocumentProcessor) addText(text string, style string) {
 	}
 }
 
+// ligatures replaces TeX's input ligatures by the characters they stand
+// for, longer ones first so that --- is not read as -- and -
+var ligatures = strings.NewReplacer(
+	"---", "—",
+	"--", "–",
+	"``", "“",
+	"''", "”",
+	"!`", "¡",
+	"?`", "¿",
+	"...", "…",
+	"`", "‘",
+	"'", "’",
+)
+
+// applyLigatures turns quotes, dashes and dots typed as in TeX sources into
+// typographic punctuation. Monospaced text keeps the characters as typed.
+func applyLigatures(text, style string) string {
+	if style == "mono" {
+		return text
+	}
+	return ligatures.Replace(text)
+}
+
+// textSymbols are the characters that commands such as \textendash print
+var textSymbols = map[string]string{
+	"textquotedbl":      "\"",
+	"textquotesingle":   "'",
+	"textquoteleft":     "‘",
+	"textquoteright":    "’",
+	"textquotedblleft":  "“",
+	"textquotedblright": "”",
+	"textendash":        "–",
+	"textemdash":        "—",
+	"textellipsis":      "…",
+	"ldots":             "…",
+	"dots":              "…",
+	"textexclamdown":    "¡",
+	"textquestiondown":  "¿",
+	"guillemotleft":     "«",
+	"guillemotright":    "»",
+	"textbackslash":     "\\",
+	"textasciitilde":    "~",
+	"textasciicircum":   "^",
+	"textbar":           "|",
+	"textless":          "<",
+	"textgreater":       ">",
+	"textbullet":        "•",
+	"textdagger":        "†",
+	"textsection":       "§",
+	"textparagraph":     "¶",
+	"textcopyright":     "©",
+	"textregistered":    "®",
+	"texttrademark":     "™",
+}
+
+// extractTypesetText returns the text of node as it is typeset, with TeX's
+// ligatures applied, for headings and other text drawn in one piece
+func (dp *DocumentProcessor) extractTypesetText(node parser.Node) string {
+	return applyLigatures(dp.extractText(node), "normal")
+}
+
+// escapedCharacters are the characters that control symbols such as \%
+// print
+var escapedCharacters = map[string]string{
+	"%": "%",
+	"$": "$",
+	"&": "&",
+	"#": "#",
+	"_": "_",
+	"{": "{",
+	"}": "}",
+	" ": " ",
+}
+
 func (dp *DocumentProcessor) extractText(node parser.Node) string {
 	switch n := node.(type) {
 	case *parser.TextNode:
 		return n.Value
+	case *parser.VerbatimNode:
+		return n.Value
 	case *parser.Group:
 		var result strings.Builder
 		for _, child := range n.Nodes {
@@ -58,8 +136,17 @@ func (dp *DocumentProcessor) extractText(node parser.Node) string {
 		if n.Name == "textit" && len(n.Args) > 0 {
 			return dp.extractText(n.Args[0])
 		}
+		if char, ok := escapedCharacters[n.Name]; ok {
+			return char
+		}
+		if symbol, ok := textSymbols[n.Name]; ok {
+			return symbol
+		}
+		if n.Name == "\\" || n.Name == "\\*" || n.Name == "newline" {
+			return " "
+		}
 		if n.Name == "today" {
-			return time.Now().Format("January 2, 2006") // Current date in LaTeX format
+			return dp.buildTime.Format("January 2, 2006") // Build date in LaTeX format
 		}
 		var result strings.Builder
 		for _, arg := range n.Args {
@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"strings"
+
+	"github.com/rickykimani/gotex/parser"
+)
+
+// tabWidth is the number of columns a tab advances to in verbatim text
+const tabWidth = 8
+
+// visibleSpace is drawn for spaces in \verb* and verbatim*
+const visibleSpace = "␣"
+
+// processVerbatimEnvironment renders the raw body of a verbatim environment
+func (dp *DocumentProcessor) processVerbatimEnvironment(env *parser.Environment) {
+	var text strings.Builder
+	for _, node := range env.Body {
+		if v, ok := node.(*parser.VerbatimNode); ok {
+			text.WriteString(v.Value)
+		}
+	}
+
+	dp.addVerbatimBlock(text.String(), strings.HasSuffix(env.Name, "*"))
+}
+
+// addVerbatimBlock renders text line by line in the monospace face, keeping
+// whitespace and line breaks. Long blocks continue on the next page.
+func (dp *DocumentProcessor) addVerbatimBlock(text string, visibleSpaces bool) {
+	if dp.lineHasContent {
+		dp.newLine()
+	}
+	dp.addVerticalSpace(dp.lineHeight * 0.3)
+
+	for _, line := range strings.Split(text, "\n") {
+		line = expandTabs(strings.TrimRight(line, "\r"))
+		dp.drawVerbatim(line, dp.currentLineX, visibleSpaces)
+		dp.newLine()
+	}
+}
+
+// addVerbatimText places inline \verb text as a single unbreakable word
+func (dp *DocumentProcessor) addVerbatimText(text string, visibleSpaces bool) {
+	text = expandTabs(text)
+	if text == "" {
+		return
+	}
+
+	width := dp.calculateTextWidth(text, "mono")
+	if dp.lineHasContent && (dp.currentLineX+width > dp.generator.PageWidth-dp.generator.MarginRight) {
+		dp.newLine()
+	}
+
+	dp.drawVerbatim(text, dp.currentLineX, visibleSpaces)
+	dp.markLink(dp.currentLineX, width)
+	dp.currentLineX += width
+	dp.lineHasContent = true
+}
+
+// drawVerbatim draws a single line of monospace text at x on the current line
+func (dp *DocumentProcessor) drawVerbatim(text string, x float64, visibleSpaces bool) {
+	if !visibleSpaces {
+		dp.generator.AddText(text, x, dp.currentY, dp.fontSize, "mono")
+		return
+	}
+
+	// The monospace face has no visible space glyph, so borrow it from the
+	// symbol font and center it in a monospace cell
+	spaceWidth := dp.calculateTextWidth(" ", "mono")
+	markWidth := dp.calculateTextWidth(visibleSpace, "normal")
+	for i, segment := range strings.Split(text, " ") {
+		if i > 0 {
+			dp.generator.AddText(visibleSpace, x+(spaceWidth-markWidth)/2, dp.currentY, dp.fontSize, "normal")
+			x += spaceWidth
+		}
+		if segment != "" {
+			dp.generator.AddText(segment, x, dp.currentY, dp.fontSize, "mono")
+			x += dp.calculateTextWidth(segment, "mono")
+		}
+	}
+}
+
+// expandTabs replaces tabs with spaces up to the next tab stop
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+
+	var result strings.Builder
+	column := 0
+	for _, char := range line {
+		if char == '\t' {
+			spaces := tabWidth - column%tabWidth
+			result.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+			continue
+		}
+		result.WriteRune(char)
+		column++
+	}
+	return result.String()
+}
@@ -9,9 +9,11 @@ func (dp *DocumentProcessor) addWord(word string, style string) {
 
 	if dp.lineHasContent && (dp.currentLineX+wordWidth > dp.generator.PageWidth-dp.generator.MarginRight) {
 		dp.newLine()
+		dp.lineBroken = true
 	}
 
 	dp.generator.AddText(word, dp.currentLineX, dp.currentY, dp.fontSize, style)
+	dp.markLink(dp.currentLineX, wordWidth)
 	dp.currentLineX += wordWidth
 	dp.lineHasContent = true
 }
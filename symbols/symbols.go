@@ -71,10 +71,52 @@ var MathSymbolTable = map[string]string{
 	"cdot":    "·",
 	"bullet":  "•",
 
+	// Dots
+	"ldots": "…",
+	"dots":  "…",
+	"hdots": "…",
+	"cdots": "⋯",
+	"vdots": "⋮",
+	"ddots": "⋱",
+
+	// Large operators
+	"coprod":    "∐",
+	"iint":      "∬",
+	"iiint":     "∭",
+	"bigcup":    "⋃",
+	"bigcap":    "⋂",
+	"bigvee":    "⋁",
+	"bigwedge":  "⋀",
+	"bigoplus":  "⨁",
+	"bigotimes": "⨂",
+	"bigodot":   "⨀",
+
+	// Delimiters
+	"langle": "⟨",
+	"rangle": "⟩",
+	"lceil":  "⌈",
+	"rceil":  "⌉",
+	"lfloor": "⌊",
+	"rfloor": "⌋",
+	"lbrace": "{",
+	"rbrace": "}",
+	"lbrack": "[",
+	"rbrack": "]",
+	"vert":   "|",
+	"lvert":  "|",
+	"rvert":  "|",
+	"Vert":   "‖",
+	"lVert":  "‖",
+	"rVert":  "‖",
+	"|":      "‖",
+
 	// Relations
 	"leq":    "≤",
 	"geq":    "≥",
 	"neq":    "≠",
+	"le":     "≤",
+	"ge":     "≥",
+	"ne":     "≠",
 	"equiv":  "≡",
 	"approx": "≈",
 	"sim":    "∼",
@@ -105,6 +147,8 @@ var MathSymbolTable = map[string]string{
 	// Arrows
 	"leftarrow":      "←",
 	"rightarrow":     "→",
+	"to":             "→",
+	"gets":           "←",
 	"uparrow":        "↑",
 	"downarrow":      "↓",
 	"leftrightarrow": "↔",
@@ -115,6 +159,24 @@ var MathSymbolTable = map[string]string{
 	"Leftrightarrow": "⇔",
 }
 
+// MathAccentTable maps LaTeX math accent commands to the accent characters
+// set over their argument
+var MathAccentTable = map[string]string{
+	"hat":       "ˆ",
+	"check":     "ˇ",
+	"tilde":     "˜",
+	"acute":     "´",
+	"grave":     "`",
+	"dot":       "˙",
+	"ddot":      "¨",
+	"breve":     "˘",
+	"bar":       "¯",
+	"vec":       "→",
+	"mathring":  "˚",
+	"widehat":   "ˆ",
+	"widetilde": "˜",
+}
+
 // ConvertMathSymbol checks if a command corresponds to a known math symbol
 func ConvertMathSymbol(command string) (string, bool) {
 	cmd := strings.TrimPrefix(command, "\\")
@@ -128,3 +190,10 @@ func IsMathSymbol(command string) bool {
 	_, exists := MathSymbolTable[cmd]
 	return exists
 }
+
+// ConvertMathAccent checks if a command is a math accent and returns its
+// accent character
+func ConvertMathAccent(command string) (string, bool) {
+	accent, exists := MathAccentTable[strings.TrimPrefix(command, "\\")]
+	return accent, exists
+}
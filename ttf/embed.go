@@ -0,0 +1,11 @@
+// Package ttf bundles the default font set so that GoTeX binaries work
+// without a font directory on disk.
+package ttf
+
+import "embed"
+
+// FS holds the fonts loaded by fonts.FontMapper when no external font
+// directory is configured. Paths mirror the layout of this directory.
+//
+//go:embed dejavu-sans/DejaVuSans.ttf dejavu-sans/DejaVuSans-Bold.ttf dejavu-sans/DejaVuSans-Oblique.ttf dejavu-sans/DejaVuSans-BoldOblique.ttf dejavu-sans/DejaVuMathTeXGyre.ttf pagella/*.ttf computer-modern/cmuntt.ttf
+var FS embed.FS